@@ -0,0 +1,236 @@
+package app
+
+import (
+	"fmt"
+	"github.com/araddon/dateparse"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/streadway/amqp"
+	"os"
+	"path"
+	"time"
+)
+
+const brokerReplayDescription = `
+Re-publish messages in dead letter directory of broker.
+Messages are removed from dead letter directory after published successfully.
+`
+
+type brokerReplayCommand struct {
+	BaseCommand
+
+	deadLetterDir string
+
+	startTime string
+	endTime   string
+	exchange  string
+	routeKey  string
+
+	dryRun bool
+}
+
+func (rc *brokerReplayCommand) runCommand(cmd *cobra.Command, args []string) error {
+	filter, err := rc.createFilter()
+	if err != nil {
+		return err
+	}
+
+	deadLetters, err := common.LoadDeadLetters(rc.deadLetterDir)
+	if err != nil {
+		return err
+	}
+
+	var selected []common.DeadLetter
+	for _, deadLetter := range deadLetters {
+		if filter.match(deadLetter) {
+			selected = append(selected, deadLetter)
+		}
+	}
+
+	if rc.dryRun {
+		for _, deadLetter := range selected {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
+				deadLetter.Time.Format(time.RFC3339),
+				deadLetter.Exchange,
+				deadLetter.RouteKey,
+				deadLetter.Reason,
+				deadLetter.Path,
+			)
+		}
+		fmt.Printf("%d of %d dead letters selected.\n", len(selected), len(deadLetters))
+		return nil
+	}
+
+	failedCount := replayDeadLetters(selected)
+
+	log.WithFields(log.Fields{
+		"component": "broker",
+		"event":     "replay",
+	}).Infof("replay dead letters: %d succeeded, %d failed", len(selected)-failedCount, failedCount)
+
+	if failedCount > 0 {
+		return fmt.Errorf("%d dead letters can't be published", failedCount)
+	}
+	return nil
+}
+
+func (rc *brokerReplayCommand) createFilter() (deadLetterFilter, error) {
+	filter := deadLetterFilter{
+		exchange: rc.exchange,
+		routeKey: rc.routeKey,
+	}
+
+	var err error
+	if len(rc.startTime) > 0 {
+		filter.startTime, err = dateparse.ParseAny(rc.startTime)
+		if err != nil {
+			return filter, fmt.Errorf("parse start time %s has error: %v", rc.startTime, err)
+		}
+	}
+	if len(rc.endTime) > 0 {
+		filter.endTime, err = dateparse.ParseAny(rc.endTime)
+		if err != nil {
+			return filter, fmt.Errorf("parse end time %s has error: %v", rc.endTime, err)
+		}
+	}
+	if _, err = path.Match(filter.routeKey, ""); err != nil {
+		return filter, fmt.Errorf("route key pattern %s is invalid: %v", rc.routeKey, err)
+	}
+	return filter, nil
+}
+
+// publish dead letters grouped by server, and remove files of published messages.
+// Return count of failed messages.
+func replayDeadLetters(deadLetters []common.DeadLetter) int {
+	deadLettersByServer := make(map[string][]common.DeadLetter)
+	for _, deadLetter := range deadLetters {
+		deadLettersByServer[deadLetter.Server] = append(deadLettersByServer[deadLetter.Server], deadLetter)
+	}
+
+	failedCount := 0
+	for server, deadLettersInServer := range deadLettersByServer {
+		connection, err := amqp.Dial(server)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"component": "broker",
+				"event":     "replay",
+			}).Errorf("failed to create connection: %v", err)
+			failedCount += len(deadLettersInServer)
+			continue
+		}
+
+		channel, err := connection.Channel()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"component": "broker",
+				"event":     "replay",
+			}).Errorf("failed to create channel: %v", err)
+			failedCount += len(deadLettersInServer)
+			connection.Close()
+			continue
+		}
+
+		for _, deadLetter := range deadLettersInServer {
+			err = sendToRabbitMQ(deadLetter.ToRabbitMQMessage(), channel)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"component": "broker",
+					"event":     "replay",
+				}).Errorf("replay %s has error: %v", deadLetter.Path, err)
+				failedCount += 1
+				continue
+			}
+			err = os.Remove(deadLetter.Path)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"component": "broker",
+					"event":     "replay",
+				}).Warnf("remove %s has error: %v", deadLetter.Path, err)
+			}
+		}
+
+		channel.Close()
+		connection.Close()
+	}
+	return failedCount
+}
+
+type deadLetterFilter struct {
+	startTime time.Time
+	endTime   time.Time
+	exchange  string
+	routeKey  string
+}
+
+func (f *deadLetterFilter) match(deadLetter common.DeadLetter) bool {
+	if !f.startTime.IsZero() && deadLetter.Time.Before(f.startTime) {
+		return false
+	}
+	if !f.endTime.IsZero() && deadLetter.Time.After(f.endTime) {
+		return false
+	}
+	if len(f.exchange) > 0 && deadLetter.Exchange != f.exchange {
+		return false
+	}
+	if len(f.routeKey) > 0 {
+		matched, _ := path.Match(f.routeKey, deadLetter.RouteKey)
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func newBrokerReplayCommand() *brokerReplayCommand {
+	rc := &brokerReplayCommand{}
+
+	replayCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "re-publish dead letters of broker",
+		Long:  brokerReplayDescription,
+		RunE:  rc.runCommand,
+	}
+
+	replayCmd.Flags().StringVar(
+		&rc.deadLetterDir,
+		"dead-letter-dir",
+		"",
+		"dead letter directory, same as --dead-letter-dir of broker command.",
+	)
+	replayCmd.Flags().StringVar(
+		&rc.startTime,
+		"start-time",
+		"",
+		"only replay messages failed after this time.",
+	)
+	replayCmd.Flags().StringVar(
+		&rc.endTime,
+		"end-time",
+		"",
+		"only replay messages failed before this time.",
+	)
+	replayCmd.Flags().StringVar(
+		&rc.exchange,
+		"exchange",
+		"",
+		"only replay messages of this exchange.",
+	)
+	replayCmd.Flags().StringVar(
+		&rc.routeKey,
+		"route-key",
+		"",
+		"only replay messages of route keys matching this pattern, such as grapes_gfs_gmf.production.*",
+	)
+	replayCmd.Flags().BoolVar(
+		&rc.dryRun,
+		"dry-run",
+		false,
+		"list selected messages without publishing them.",
+	)
+
+	replayCmd.MarkFlagRequired("dead-letter-dir")
+
+	rc.cmd = replayCmd
+	return rc
+}
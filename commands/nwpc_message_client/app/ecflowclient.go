@@ -43,6 +43,8 @@ func newEcflowClientCommand() *ecflowClientCommand {
 		ec.printHelp()
 	})
 
+	ecFlowClientCmd.AddCommand(newEcflowClientExecCommand().getCommand())
+
 	ec.cmd = ecFlowClientCmd
 	return ec
 }
@@ -55,6 +57,7 @@ type ecflowClientCommand struct {
 		help           bool
 	}
 
+	envCaptureOptions
 	targetParser
 }
 
@@ -77,6 +80,7 @@ func (ec *ecflowClientCommand) runCommand(cmd *cobra.Command, args []string) err
 	if err != nil {
 		return err
 	}
+	data.CaptureEnvironment(ec.createEnvCapture())
 
 	message := common.EventMessage{
 		App:  appName,
@@ -97,6 +101,7 @@ func (ec *ecflowClientCommand) generateMainFlags() *pflag.FlagSet {
 			"--host=login_a06 --port=33083 "+
 			"--alter add variable ECF_RID 16934800 "+
 			"/gmf_grapes_gfs_post/00/togrib2/togrib2_gfs/045/ne_grib2_045")
+	ec.addEnvCaptureFlags(mainFlagSet)
 	mainFlagSet.BoolVar(&ec.mainOptions.help, "help", false, "print usage")
 
 	mainFlagSet.SetAnnotation("command-options", commands.RequiredOption, []string{"true"})
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
+)
+
+// Environment variables of default values of --capture-env and --redact-env, separated by comma.
+const (
+	EnvCaptureEnv = "NWPC_MESSAGE_CLIENT_CAPTURE_ENV"
+	EnvRedactEnv  = "NWPC_MESSAGE_CLIENT_REDACT_ENV"
+)
+
+// envCaptureOptions are flags of environment variables sent in ecflow-client messages.
+type envCaptureOptions struct {
+	captureEnv []string
+	redactEnv  []string
+}
+
+func (o *envCaptureOptions) addEnvCaptureFlags(flagSet *pflag.FlagSet) {
+	flagSet.StringSliceVar(&o.captureEnv, "capture-env", splitEnvList(os.Getenv(EnvCaptureEnv)),
+		"environment variables sent in message, names or patterns such as SLURM_*,PBS_*, may be set by "+EnvCaptureEnv)
+	flagSet.StringSliceVar(&o.redactEnv, "redact-env", splitEnvList(os.Getenv(EnvRedactEnv)),
+		"patterns of variable names whose values are redacted, besides secret-looking names, case-insensitive, may be set by "+EnvRedactEnv)
+}
+
+func (o *envCaptureOptions) createEnvCapture() *common.EnvCapture {
+	return &common.EnvCapture{
+		Names:          o.captureEnv,
+		RedactPatterns: append(append([]string(nil), common.DefaultRedactPatterns...), o.redactEnv...),
+	}
+}
+
+func splitEnvList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
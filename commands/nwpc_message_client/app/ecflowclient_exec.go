@@ -0,0 +1,310 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// EnvEcflowClient sets path of ecflow_client program used by ecflow-client exec command.
+const EnvEcflowClient = "NWPC_MESSAGE_CLIENT_ECFLOW_CLIENT"
+
+// max size of stderr tail in ecflow-client message.
+const maxStderrTailSize = 2048
+
+const ecflowClientExecDescription = `
+Run ecflow_client and send a message for the command.
+
+  nwpc_message_client ecflow-client exec [flags] -- <ecflow_client args>
+
+Output of ecflow_client is not changed, and exit code of ecflow_client is kept.
+Message contains exit code, duration and tail of stderr of ecflow_client.
+Message delivery is limited by --send-timeout and never changes the exit code.
+If target options are invalid, ecflow_client is still run and only the message is skipped.
+`
+
+type ecflowClientExecCommand struct {
+	BaseCommand
+
+	mainOptions struct {
+		ecflowClient string
+		sendTimeout  time.Duration
+		help         bool
+	}
+
+	envCaptureOptions
+	targetParser
+}
+
+func newEcflowClientExecCommand() *ecflowClientExecCommand {
+	ec := &ecflowClientExecCommand{
+		targetParser: targetParser{
+			defaultOption: targetOptions{
+				writeTimeout: 2 * time.Second,
+				brokerTries:  2,
+				exchangeName: "nwpc.operation.workflow",
+				routeKeyName: "ecflow.command.ecflow_client",
+			},
+		},
+	}
+	execCmd := &cobra.Command{
+		Use:                "exec [flags] -- <ecflow_client args>",
+		Short:              "run ecflow_client and send messages for the command",
+		Long:               ecflowClientExecDescription,
+		RunE:               ec.runCommand,
+		DisableFlagParsing: true,
+	}
+	execCmd.SetUsageFunc(func(*cobra.Command) error {
+		ec.printHelp()
+		return nil
+	})
+	execCmd.SetHelpFunc(func(*cobra.Command, []string) {
+		ec.printHelp()
+	})
+
+	ec.cmd = execCmd
+	return ec
+}
+
+func (ec *ecflowClientExecCommand) runCommand(cmd *cobra.Command, args []string) error {
+	options, ecflowClientArgs := splitExecArgs(args)
+
+	// ecflow_client is always run, errors of options for messages are only logged.
+	err := ec.parseMainOptions(options)
+	if ec.mainOptions.help {
+		ec.printHelp()
+		return nil
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "ecflow-client",
+			"event":     "option",
+		}).Warnf("parse main options has error: %v", err)
+	}
+
+	targetErr := ec.targetParser.parseCommandTargetOptions(options)
+	if targetErr != nil {
+		log.WithFields(log.Fields{
+			"component": "ecflow-client",
+			"event":     "option",
+		}).Warnf("parse target options has error, message is not sent: %v", targetErr)
+	}
+
+	startTime := time.Now()
+	exitCode, stderrTail, err := runEcflowClient(ec.mainOptions.ecflowClient, ecflowClientArgs)
+	if err != nil {
+		return err
+	}
+	duration := time.Since(startTime)
+
+	if targetErr == nil {
+		ec.sendMessage(ecflowClientArgs, exitCode, duration, stderrTail)
+	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+	return nil
+}
+
+// create and send message, waiting at most send timeout, so that task is not blocked by message platform.
+func (ec *ecflowClientExecCommand) sendMessage(args []string, exitCode int, duration time.Duration, stderrTail string) {
+	err := sendMessageWithTimeout(ec.targetParser.option, ec.mainOptions.sendTimeout, func() (common.EventMessage, error) {
+		data, err := common.CreateEcflowClientMessageFromArgs(args)
+		if err != nil {
+			return common.EventMessage{}, err
+		}
+		data.CaptureEnvironment(ec.createEnvCapture())
+		data.ExitCode = &exitCode
+		data.Duration = duration.Seconds()
+		data.StderrTail = stderrTail
+
+		return common.EventMessage{
+			App:  appName,
+			Type: EcflowClientMessageType,
+			Time: time.Now(),
+			Data: data,
+		}, nil
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "ecflow-client",
+			"event":     "send",
+		}).Warnf("send message has error: %v", err)
+	}
+}
+
+// create message and send it in background, and return error after timeout.
+func sendMessageWithTimeout(
+	options targetOptions,
+	timeout time.Duration,
+	createMessage func() (common.EventMessage, error),
+) error {
+	done := make(chan error, 1)
+	go func() {
+		message, err := createMessage()
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- sendExecMessage(options, message)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout after %v", timeout)
+	}
+}
+
+// send message without printing it, so that stdout of wrapped command is not changed.
+func sendExecMessage(options targetOptions, message common.EventMessage) error {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("marshal message has error: %v", err)
+	}
+	if options.disableSend {
+		log.WithFields(log.Fields{
+			"component": "message",
+			"event":     "send",
+		}).Infof("message deliver is disabled by --disable-send option: %s", messageBytes)
+		return nil
+	}
+
+	currentSender, err := createTargetSender(options)
+	if err != nil {
+		return err
+	}
+	return sendMessage(currentSender, messageBytes)
+}
+
+// run ecflow_client with stdin, stdout and stderr of current process,
+// forward interrupt and terminate signals, and return exit code and tail of stderr.
+func runEcflowClient(program string, args []string) (int, string, error) {
+	stderrTail := &tailBuffer{limit: maxStderrTailSize}
+
+	command := exec.Command(program, args...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = io.MultiWriter(os.Stderr, stderrTail)
+
+	err := command.Start()
+	if err != nil {
+		return 0, "", fmt.Errorf("start %s has error: %v", program, err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for s := range signals {
+			command.Process.Signal(s)
+		}
+	}()
+
+	err = command.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 0, "", fmt.Errorf("run %s has error: %v", program, err)
+	}
+
+	exitCode := command.ProcessState.ExitCode()
+	if exitCode < 0 {
+		// killed by signal
+		exitCode = 128
+		if status, ok := command.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			exitCode += int(status.Signal())
+		}
+	}
+	return exitCode, stderrTail.String(), nil
+}
+
+// split args by the first --. Args before -- are options of nwpc_message_client,
+// and args after -- are passed to ecflow_client.
+func splitExecArgs(args []string) ([]string, []string) {
+	for index, arg := range args {
+		if arg == "--" {
+			return args[:index], args[index+1:]
+		}
+	}
+	return args, nil
+}
+
+func (ec *ecflowClientExecCommand) generateMainFlags() *pflag.FlagSet {
+	mainFlagSet := pflag.NewFlagSet("main", pflag.ContinueOnError)
+	mainFlagSet.ParseErrorsWhitelist.UnknownFlags = true
+
+	defaultEcflowClient := os.Getenv(EnvEcflowClient)
+	if defaultEcflowClient == "" {
+		defaultEcflowClient = "ecflow_client"
+	}
+	mainFlagSet.StringVar(&ec.mainOptions.ecflowClient, "ecflow-client", defaultEcflowClient,
+		"path of ecflow_client program, may be set by "+EnvEcflowClient)
+	mainFlagSet.DurationVar(&ec.mainOptions.sendTimeout, "send-timeout", 10*time.Second,
+		"max time to wait for message delivery after ecflow_client exits")
+	ec.addEnvCaptureFlags(mainFlagSet)
+	mainFlagSet.BoolVar(&ec.mainOptions.help, "help", false, "print usage")
+
+	return mainFlagSet
+}
+
+func (ec *ecflowClientExecCommand) parseMainOptions(args []string) error {
+	mainFlagSet := ec.generateMainFlags()
+	err := mainFlagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse options has error: %s", err)
+	}
+	return nil
+}
+
+func (ec *ecflowClientExecCommand) printHelp() {
+	helpOutput := os.Stdout
+	fmt.Fprintf(helpOutput, "%s\n", ecflowClientExecDescription)
+
+	mainFlags := ec.generateMainFlags()
+	mainFlags.SetOutput(helpOutput)
+	fmt.Fprintf(helpOutput, "Main Flags:\n")
+	mainFlags.PrintDefaults()
+
+	fmt.Fprintf(helpOutput, "\n")
+	targetFlags := ec.targetParser.generateFlags()
+	targetFlags.SetOutput(helpOutput)
+	fmt.Fprintf(helpOutput, "Target Flags:\n")
+	targetFlags.PrintDefaults()
+}
+
+// tailBuffer keeps the last limit bytes written into it.
+type tailBuffer struct {
+	limit int
+
+	lock sync.Mutex
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = append([]byte(nil), b.data[len(b.data)-b.limit:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return string(b.data)
+}
@@ -0,0 +1,360 @@
+package app
+
+import (
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
+	"time"
+)
+
+const JobMessageType = "job"
+
+// EnvScheduler sets default scheduler of job commands.
+const EnvScheduler = "NWPC_MESSAGE_CLIENT_SCHEDULER"
+
+const jobDescription = `
+Submit or cancel ecFlow jobs using Slurm, PBS or shell, and send job messages.
+Use as ECF_JOB_CMD and ECF_KILL_CMD of ecFlow, for example:
+
+  nwpc_message_client job submit --scheduler slurm --ecf-job %ECF_JOB% --ecf-name %ECF_NAME% ...
+  nwpc_message_client job cancel --scheduler slurm --job-id %ECF_RID% --ecf-name %ECF_NAME% ...
+
+Options --ecf-* use values of ECF_* environment variables by default.
+`
+
+func newJobCommand() *jobCommand {
+	jc := &jobCommand{}
+	jobCmd := &cobra.Command{
+		Use:   "job",
+		Short: "submit or cancel jobs and send job messages",
+		Long:  jobDescription,
+	}
+	jobCmd.AddCommand(
+		newJobSubmitCommand().getCommand(),
+		newJobCancelCommand().getCommand(),
+	)
+	jc.cmd = jobCmd
+	return jc
+}
+
+type jobCommand struct {
+	BaseCommand
+}
+
+// jobOptions are options shared by job submit and cancel commands.
+type jobOptions struct {
+	scheduler   string
+	sendTimeout time.Duration
+
+	ecfName  string
+	ecfHost  string
+	ecfPort  string
+	ecfTryNo string
+	ecfTries string
+	ecfDate  string
+
+	help bool
+}
+
+func (o *jobOptions) addJobFlags(flagSet *pflag.FlagSet) {
+	defaultScheduler := os.Getenv(EnvScheduler)
+	if defaultScheduler == "" {
+		defaultScheduler = common.SchedulerShell
+	}
+	flagSet.StringVar(&o.scheduler, "scheduler", defaultScheduler,
+		"job scheduler: slurm, pbs or shell, may be set by "+EnvScheduler)
+	flagSet.DurationVar(&o.sendTimeout, "send-timeout", 10*time.Second,
+		"max time to wait for message delivery")
+
+	flagSet.StringVar(&o.ecfName, "ecf-name", os.Getenv("ECF_NAME"), "node path, ECF_NAME")
+	flagSet.StringVar(&o.ecfHost, "ecf-host", os.Getenv("ECF_HOST"), "ecFlow host, ECF_HOST")
+	flagSet.StringVar(&o.ecfPort, "ecf-port", os.Getenv("ECF_PORT"), "ecFlow port, ECF_PORT")
+	flagSet.StringVar(&o.ecfTryNo, "ecf-tryno", os.Getenv("ECF_TRYNO"), "try number, ECF_TRYNO")
+	flagSet.StringVar(&o.ecfTries, "ecf-tries", os.Getenv("ECF_TRIES"), "max tries, ECF_TRIES")
+	flagSet.StringVar(&o.ecfDate, "ecf-date", os.Getenv("ECF_DATE"), "ECF_DATE")
+
+	flagSet.BoolVar(&o.help, "help", false, "print usage")
+}
+
+func (o *jobOptions) createJobEventData(event string) *common.JobEventData {
+	return &common.JobEventData{
+		Event:      event,
+		Scheduler:  o.scheduler,
+		EcflowHost: o.ecfHost,
+		EcflowPort: o.ecfPort,
+		NodeName:   o.ecfName,
+		TryNo:      o.ecfTryNo,
+		Tries:      o.ecfTries,
+		EcfDate:    o.ecfDate,
+	}
+}
+
+// parse target options and log error, so that job is submitted or cancelled even if message config is broken.
+// Returns whether message can be sent.
+func parseJobTargetOptions(parser *targetParser, args []string) bool {
+	err := parser.parseCommandTargetOptions(args)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "job",
+			"event":     "option",
+		}).Warnf("parse target options has error, message is not sent: %v", err)
+		return false
+	}
+	return true
+}
+
+// set result of job event, send job message and log delivery error.
+// Job command is not failed by message delivery.
+func sendJobMessage(options targetOptions, timeout time.Duration, data *common.JobEventData, err error) {
+	if err != nil {
+		data.Result = common.JobResultFailed
+		data.Error = err.Error()
+	} else {
+		data.Result = common.JobResultSucceeded
+	}
+
+	sendErr := sendMessageWithTimeout(options, timeout, func() (common.EventMessage, error) {
+		return common.EventMessage{
+			App:  appName,
+			Type: JobMessageType,
+			Time: time.Now(),
+			Data: data,
+		}, nil
+	})
+	if sendErr != nil {
+		log.WithFields(log.Fields{
+			"component": "job",
+			"event":     "send",
+		}).Warnf("send message has error: %v", sendErr)
+	}
+}
+
+func printJobHelp(description string, mainFlags *pflag.FlagSet, parser *targetParser) {
+	helpOutput := os.Stdout
+	fmt.Fprintf(helpOutput, "%s\n", description)
+
+	mainFlags.SetOutput(helpOutput)
+	fmt.Fprintf(helpOutput, "Main Flags:\n")
+	mainFlags.PrintDefaults()
+
+	fmt.Fprintf(helpOutput, "\n")
+	targetFlags := parser.generateFlags()
+	targetFlags.SetOutput(helpOutput)
+	fmt.Fprintf(helpOutput, "Target Flags:\n")
+	targetFlags.PrintDefaults()
+}
+
+const jobSubmitDescription = `
+Submit ecFlow job script and send a job message with job id.
+
+  nwpc_message_client job submit [flags] [-- <submitter args>]
+
+Job id is printed to stdout. Args after -- are passed to submit command, such as sbatch.
+`
+
+type jobSubmitCommand struct {
+	BaseCommand
+
+	mainOptions struct {
+		jobOptions
+		submitCommand string
+		queue         string
+		ecfJob        string
+		ecfJobOut     string
+	}
+
+	targetParser
+}
+
+func newJobSubmitCommand() *jobSubmitCommand {
+	jc := &jobSubmitCommand{
+		targetParser: targetParser{
+			defaultOption: targetOptions{
+				writeTimeout: 2 * time.Second,
+				brokerTries:  2,
+				exchangeName: "nwpc.operation.workflow",
+				routeKeyName: "ecflow.job.submit",
+			},
+		},
+	}
+	submitCmd := &cobra.Command{
+		Use:                "submit",
+		Short:              "submit job and send job message",
+		Long:               jobSubmitDescription,
+		RunE:               jc.runCommand,
+		DisableFlagParsing: true,
+	}
+	submitCmd.SetUsageFunc(func(*cobra.Command) error {
+		printJobHelp(jobSubmitDescription, jc.generateMainFlags(), &jc.targetParser)
+		return nil
+	})
+	submitCmd.SetHelpFunc(func(*cobra.Command, []string) {
+		printJobHelp(jobSubmitDescription, jc.generateMainFlags(), &jc.targetParser)
+	})
+
+	jc.cmd = submitCmd
+	return jc
+}
+
+func (jc *jobSubmitCommand) generateMainFlags() *pflag.FlagSet {
+	mainFlagSet := pflag.NewFlagSet("main", pflag.ContinueOnError)
+	mainFlagSet.ParseErrorsWhitelist.UnknownFlags = true
+
+	mainFlagSet.StringVar(&jc.mainOptions.submitCommand, "submit-command", "",
+		"submit command, default is sbatch for slurm and qsub for pbs")
+	mainFlagSet.StringVar(&jc.mainOptions.queue, "queue", "", "queue or partition of job")
+	mainFlagSet.StringVar(&jc.mainOptions.ecfJob, "ecf-job", os.Getenv("ECF_JOB"), "job script, ECF_JOB")
+	mainFlagSet.StringVar(&jc.mainOptions.ecfJobOut, "ecf-jobout", os.Getenv("ECF_JOBOUT"),
+		"job output, ECF_JOBOUT, used by shell scheduler")
+	jc.mainOptions.addJobFlags(mainFlagSet)
+
+	return mainFlagSet
+}
+
+func (jc *jobSubmitCommand) runCommand(cmd *cobra.Command, args []string) error {
+	options, submitterArgs := splitExecArgs(args)
+	err := jc.generateMainFlags().Parse(options)
+	if err != nil {
+		return fmt.Errorf("parse main options has eror: %v", err)
+	}
+	if jc.mainOptions.help {
+		printJobHelp(jobSubmitDescription, jc.generateMainFlags(), &jc.targetParser)
+		return nil
+	}
+	if jc.mainOptions.ecfJob == "" {
+		return fmt.Errorf("job script is not set by --ecf-job or ECF_JOB")
+	}
+
+	canSend := parseJobTargetOptions(&jc.targetParser, options)
+
+	scheduler, err := common.CreateJobScheduler(jc.mainOptions.scheduler, jc.mainOptions.submitCommand, "")
+	if err != nil {
+		return err
+	}
+
+	jobID, output, err := scheduler.Submit(
+		jc.mainOptions.ecfJob,
+		jc.mainOptions.queue,
+		jc.mainOptions.ecfJobOut,
+		submitterArgs,
+	)
+	if err != nil && output != "" {
+		err = fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	data := jc.mainOptions.createJobEventData(common.JobEventSubmit)
+	data.JobID = jobID
+	data.Queue = jc.mainOptions.queue
+	data.EcfJob = jc.mainOptions.ecfJob
+	data.EcfJobOut = jc.mainOptions.ecfJobOut
+	if canSend {
+		sendJobMessage(jc.targetParser.option, jc.mainOptions.sendTimeout, data, err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("submit job has error: %v", err)
+	}
+	fmt.Println(jobID)
+	return nil
+}
+
+const jobCancelDescription = `
+Cancel job by job id and send a job message.
+
+  nwpc_message_client job cancel [flags]
+`
+
+type jobCancelCommand struct {
+	BaseCommand
+
+	mainOptions struct {
+		jobOptions
+		cancelCommand string
+		jobID         string
+	}
+
+	targetParser
+}
+
+func newJobCancelCommand() *jobCancelCommand {
+	jc := &jobCancelCommand{
+		targetParser: targetParser{
+			defaultOption: targetOptions{
+				writeTimeout: 2 * time.Second,
+				brokerTries:  2,
+				exchangeName: "nwpc.operation.workflow",
+				routeKeyName: "ecflow.job.cancel",
+			},
+		},
+	}
+	cancelCmd := &cobra.Command{
+		Use:                "cancel",
+		Short:              "cancel job and send job message",
+		Long:               jobCancelDescription,
+		RunE:               jc.runCommand,
+		DisableFlagParsing: true,
+	}
+	cancelCmd.SetUsageFunc(func(*cobra.Command) error {
+		printJobHelp(jobCancelDescription, jc.generateMainFlags(), &jc.targetParser)
+		return nil
+	})
+	cancelCmd.SetHelpFunc(func(*cobra.Command, []string) {
+		printJobHelp(jobCancelDescription, jc.generateMainFlags(), &jc.targetParser)
+	})
+
+	jc.cmd = cancelCmd
+	return jc
+}
+
+func (jc *jobCancelCommand) generateMainFlags() *pflag.FlagSet {
+	mainFlagSet := pflag.NewFlagSet("main", pflag.ContinueOnError)
+	mainFlagSet.ParseErrorsWhitelist.UnknownFlags = true
+
+	mainFlagSet.StringVar(&jc.mainOptions.cancelCommand, "cancel-command", "",
+		"cancel command, default is scancel for slurm and qdel for pbs")
+	mainFlagSet.StringVar(&jc.mainOptions.jobID, "job-id", os.Getenv("ECF_RID"), "job id, ECF_RID")
+	jc.mainOptions.addJobFlags(mainFlagSet)
+
+	return mainFlagSet
+}
+
+func (jc *jobCancelCommand) runCommand(cmd *cobra.Command, args []string) error {
+	err := jc.generateMainFlags().Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse main options has eror: %v", err)
+	}
+	if jc.mainOptions.help {
+		printJobHelp(jobCancelDescription, jc.generateMainFlags(), &jc.targetParser)
+		return nil
+	}
+	if jc.mainOptions.jobID == "" {
+		return fmt.Errorf("job id is not set by --job-id or ECF_RID")
+	}
+
+	canSend := parseJobTargetOptions(&jc.targetParser, args)
+
+	scheduler, err := common.CreateJobScheduler(jc.mainOptions.scheduler, "", jc.mainOptions.cancelCommand)
+	if err != nil {
+		return err
+	}
+
+	output, err := scheduler.Cancel(jc.mainOptions.jobID)
+	if err != nil && output != "" {
+		err = fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	data := jc.mainOptions.createJobEventData(common.JobEventCancel)
+	data.JobID = jc.mainOptions.jobID
+	if canSend {
+		sendJobMessage(jc.targetParser.option, jc.mainOptions.sendTimeout, data, err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cancel job has error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package app
+
+import (
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/commands"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
+	"time"
+)
+
+const PredictMessageType = "predict"
+
+const predictDescription = `
+Send predicted finish time of a task or a product.
+Messages are consumed by nwpc_message_consumer predict.
+
+  nwpc_message_client predict --system grapes_gfs_gmf --start-time 2021042200 \
+    --task /grapes_gfs_gmf/00/model --predicted-time +3h20m --method history
+
+--predicted-time is a time in RFC3339, such as 2021-04-22T03:20:00Z,
+or an offset from start time, such as +3h20m.
+`
+
+func newPredictCommand() *predictCommand {
+	pc := &predictCommand{
+		targetParser: targetParser{
+			defaultOption: targetOptions{
+				brokerTries:  2,
+				writeTimeout: 2 * time.Second,
+				exchangeName: "nwpc.operation.predict",
+			},
+		},
+	}
+
+	predictCmd := &cobra.Command{
+		Use:                "predict",
+		Short:              "send predict messages",
+		Long:               predictDescription,
+		RunE:               pc.runCommand,
+		DisableFlagParsing: true,
+	}
+	predictCmd.SetUsageFunc(func(*cobra.Command) error {
+		pc.printHelp()
+		return nil
+	})
+	predictCmd.SetHelpFunc(func(*cobra.Command, []string) {
+		pc.printHelp()
+	})
+
+	pc.cmd = predictCmd
+	return pc
+}
+
+type predictCommand struct {
+	BaseCommand
+
+	mainOptions struct {
+		system        string
+		startTime     string
+		task          string
+		product       string
+		predictedTime string
+		method        string
+		confidence    float64
+
+		help bool
+	}
+
+	targetParser
+}
+
+func (pc *predictCommand) runCommand(cmd *cobra.Command, args []string) error {
+	err := pc.parseMainOptions(args)
+	if pc.mainOptions.help {
+		pc.printHelp()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("parse main options has error: %v", err)
+	}
+
+	err = pc.targetParser.parseCommandTargetOptions(args)
+	if err != nil {
+		return fmt.Errorf("parser target options has error: %v", err)
+	}
+
+	data, err := pc.createPredictData()
+	if err != nil {
+		return fmt.Errorf("create predict data has error: %v", err)
+	}
+
+	return pc.sendPredictMessage(data)
+}
+
+func (pc *predictCommand) parseMainOptions(args []string) error {
+	flagSet := pc.generateMainFlags()
+	err := flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse options has error: %s", err)
+	}
+	if pc.mainOptions.help {
+		return nil
+	}
+
+	err = commands.CheckRequiredFlags(flagSet)
+	if err != nil {
+		return fmt.Errorf("check required flags has error: %v", err)
+	}
+	return nil
+}
+
+func (pc *predictCommand) generateMainFlags() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("main", pflag.ContinueOnError)
+	flagSet.SortFlags = false
+	flagSet.ParseErrorsWhitelist.UnknownFlags = true
+
+	flagSet.StringVar(&pc.mainOptions.system, "system", "",
+		"system name, such as grapes_gfs_gmf")
+	flagSet.StringVar(&pc.mainOptions.startTime, "start-time", "",
+		"start time of the run, YYYYMMDDHH")
+	flagSet.StringVar(&pc.mainOptions.task, "task", "",
+		"ecFlow node path of the task, such as /grapes_gfs_gmf/00/model")
+	flagSet.StringVar(&pc.mainOptions.product, "product", "",
+		"product, such as grib2.orig.024h")
+	flagSet.StringVar(&pc.mainOptions.predictedTime, "predicted-time", "",
+		"predicted finish time, RFC3339 time or offset from start time such as +3h20m")
+	flagSet.StringVar(&pc.mainOptions.method, "method", common.PredictMethodHistory,
+		fmt.Sprintf("predict method, such as %s, %s, %s",
+			common.PredictMethodHistory, common.PredictMethodModel, common.PredictMethodManual))
+	flagSet.Float64Var(&pc.mainOptions.confidence, "confidence", -1,
+		"confidence of prediction in [0, 1], not sent if it is negative")
+
+	flagSet.BoolVar(&pc.mainOptions.help, "help", false, "print usage")
+
+	flagSet.SetAnnotation("system", commands.RequiredOption, []string{"true"})
+	flagSet.SetAnnotation("start-time", commands.RequiredOption, []string{"true"})
+	flagSet.SetAnnotation("predicted-time", commands.RequiredOption, []string{"true"})
+	return flagSet
+}
+
+func (pc *predictCommand) createPredictData() (*common.PredictData, error) {
+	if (pc.mainOptions.task == "") == (pc.mainOptions.product == "") {
+		return nil, fmt.Errorf("one of --task and --product should be set")
+	}
+	if pc.mainOptions.method == "" {
+		return nil, fmt.Errorf("--method should not be empty")
+	}
+	if pc.mainOptions.confidence > 1 {
+		return nil, fmt.Errorf("--confidence should be in [0, 1]: %v", pc.mainOptions.confidence)
+	}
+
+	startTime, err := time.Parse("2006010215", pc.mainOptions.startTime)
+	if err != nil {
+		return nil, fmt.Errorf("parse start time %s has error: %v", pc.mainOptions.startTime, err)
+	}
+	predictedTime, err := parsePredictedTime(pc.mainOptions.predictedTime, startTime)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &common.PredictData{
+		System:        pc.mainOptions.system,
+		StartTime:     startTime,
+		Task:          pc.mainOptions.task,
+		Product:       pc.mainOptions.product,
+		PredictedTime: predictedTime,
+		Method:        pc.mainOptions.method,
+	}
+	if pc.mainOptions.confidence >= 0 {
+		confidence := pc.mainOptions.confidence
+		data.Confidence = &confidence
+	}
+	return data, nil
+}
+
+// parse RFC3339 time or offset from start time, such as +3h20m.
+func parsePredictedTime(value string, startTime time.Time) (time.Time, error) {
+	if strings.HasPrefix(value, "+") {
+		offset, err := time.ParseDuration(value[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse predicted time offset %s has error: %v", value, err)
+		}
+		return startTime.Add(offset), nil
+	}
+	predictedTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse predicted time %s has error: %v", value, err)
+	}
+	return predictedTime, nil
+}
+
+// route key is system.predict.task or system.predict.product, consumed by *.predict.* topic.
+func (pc *predictCommand) sendPredictMessage(data *common.PredictData) error {
+	message := common.EventMessage{
+		App:  appName,
+		Type: PredictMessageType,
+		Time: time.Now(),
+		Data: data,
+	}
+
+	target := "task"
+	if data.Product != "" {
+		target = "product"
+	}
+	if pc.targetParser.option.routeKeyName == "" {
+		pc.targetParser.option.routeKeyName = fmt.Sprintf("%s.predict.%s", data.System, target)
+	}
+
+	return sendEventMessageToTarget(pc.targetParser.option, message)
+}
+
+func (pc *predictCommand) printHelp() {
+	helpOutput := os.Stdout
+	fmt.Fprintf(helpOutput, "%s\n", predictDescription)
+
+	mainFlags := pc.generateMainFlags()
+	mainFlags.SetOutput(helpOutput)
+	fmt.Fprintf(helpOutput, "Main Flags:\n")
+	mainFlags.PrintDefaults()
+
+	fmt.Fprintf(helpOutput, "\n")
+	targetFlags := pc.targetParser.generateFlags()
+	targetFlags.SetOutput(helpOutput)
+	fmt.Fprintf(helpOutput, "Target Flags:\n")
+	targetFlags.PrintDefaults()
+}
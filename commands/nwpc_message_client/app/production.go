@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/nwpc-oper/nwpc-message-client/commands"
 	"github.com/nwpc-oper/nwpc-message-client/common"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"os"
@@ -12,6 +13,9 @@ import (
 
 const ProductionMessageType = "production"
 
+// EnvCatalogue sets product catalogue file used by production command.
+const EnvCatalogue = "NWPC_MESSAGE_CLIENT_CATALOGUE"
+
 const productionDescription = `
 Send messages for production.
 Messages are send to a rabbitmq server directly or via a broker running by broker command.
@@ -43,6 +47,8 @@ func newProductionCommand() *productionCommand {
 		pc.printHelp()
 	})
 
+	productionCmd.AddCommand(newProductionWatchCommand().getCommand())
+
 	pc.cmd = productionCmd
 	return pc
 }
@@ -62,6 +68,18 @@ type productionCommand struct {
 		event  string
 		status string
 
+		// fields of some events, such as destination host of transfer event.
+		eventDetail common.ProductionEventDetail
+
+		catalogue     string
+		catalogueMode string
+
+		files     []string
+		fileGlobs []string
+		checksum  string
+
+		fromGrib2 string
+
 		help bool
 	}
 
@@ -83,13 +101,26 @@ func (pc *productionCommand) runCommand(cmd *cobra.Command, args []string) error
 		return fmt.Errorf("parser target options has error: %v", err)
 	}
 
-	data, err := pc.createProductionData(args)
+	dataList, err := pc.createCheckedProductionData(args)
+	if err != nil {
+		return err
+	}
+
+	return pc.sendProductionMessages(dataList)
+}
 
+// create production data and check it with product catalogue. Options should be parsed before.
+func (pc *productionCommand) createCheckedProductionData(args []string) ([]interface{}, error) {
+	dataList, err := pc.createProductionData(args)
 	if err != nil {
-		return fmt.Errorf("create production data has error: %s", err)
+		return nil, fmt.Errorf("create production data has error: %s", err)
 	}
 
-	return pc.sendProductionMessage(data)
+	err = pc.checkCatalogue(dataList)
+	if err != nil {
+		return nil, err
+	}
+	return dataList, nil
 }
 
 func (pc *productionCommand) parseMainOptions(args []string) error {
@@ -107,10 +138,17 @@ func (pc *productionCommand) parseMainOptions(args []string) error {
 		return fmt.Errorf("check required flags has error: %v", err)
 	}
 
-	pc.fillProductionInfo()
-	pc.fillProductionEventStatus()
+	err = common.CheckCatalogueMode(pc.mainOptions.catalogueMode)
+	if err != nil {
+		return err
+	}
+	err = common.CheckChecksumAlgorithm(pc.mainOptions.checksum)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	pc.fillProductionInfo()
+	return pc.fillProductionEventStatus()
 }
 
 func (pc *productionCommand) generateCommandMainParser() *pflag.FlagSet {
@@ -121,15 +159,46 @@ func (pc *productionCommand) generateCommandMainParser() *pflag.FlagSet {
 	flagSet.StringVar((*string)(&pc.mainOptions.productionType), "production-type", "",
 		fmt.Sprintf("production type, such as %s", common.ProductionTypeGrib2))
 	flagSet.StringVar((*string)(&pc.mainOptions.stream), "production-stream", "",
-		"production stream, such as oper")
+		fmt.Sprintf("production stream, such as %s", productionStreamNames()))
 	flagSet.StringVar(&pc.mainOptions.productionName, "production-name", "",
 		"production name, such as orig")
 
 	flagSet.StringVar(&pc.mainOptions.event, "event", "",
-		fmt.Sprintf("production event, such as %s", common.ProductionEventStorage))
-	flagSet.StringVar(&pc.mainOptions.status, "status", string(common.Complete),
+		fmt.Sprintf("production event: %s, %s, %s, %s, %s, %s or %s",
+			common.ProductionEventGeneration, common.ProductionEventCheck, common.ProductionEventConversion,
+			common.ProductionEventStorage, common.ProductionEventArchive, common.ProductionEventTransfer,
+			common.ProductionEventDeletion))
+	flagSet.StringVar(&pc.mainOptions.status, "status", common.Complete.String(),
 		fmt.Sprintf("event status, such as %s, %s", common.Complete, common.Aborted))
 
+	detail := &pc.mainOptions.eventDetail
+	flagSet.StringVar(&detail.CheckResult, "check-result", "",
+		fmt.Sprintf("result of check event: %s, %s or %s",
+			common.CheckResultPassed, common.CheckResultWarning, common.CheckResultFailed))
+	flagSet.StringVar(&detail.CheckMessage, "check-message", "", "message of check event")
+	flagSet.StringVar(&detail.SourceFormat, "source-format", "", "source format of conversion event, such as grib1")
+	flagSet.StringVar(&detail.TargetFormat, "target-format", "", "target format of conversion event, such as grib2")
+	flagSet.StringVar(&detail.ArchivePath, "archive-path", "", "archive path of archive event")
+	flagSet.StringVar(&detail.DestinationHost, "destination-host", "", "destination host of transfer event")
+	flagSet.StringVar(&detail.DestinationPath, "destination-path", "", "destination path of transfer event")
+
+	flagSet.StringVar(&pc.mainOptions.catalogue, "catalogue", os.Getenv(EnvCatalogue),
+		"product catalogue file to check messages, may be set by "+EnvCatalogue)
+	flagSet.StringVar(&pc.mainOptions.catalogueMode, "catalogue-mode", common.CatalogueModeStrict,
+		fmt.Sprintf("catalogue check mode: %s rejects invalid messages, %s only prints warnings",
+			common.CatalogueModeStrict, common.CatalogueModeWarn))
+
+	flagSet.StringArrayVar(&pc.mainOptions.files, "file", nil,
+		"file of the product, add path, size, modify time and checksum into message, can be repeated")
+	flagSet.StringArrayVar(&pc.mainOptions.fileGlobs, "file-glob", nil,
+		"glob pattern of files of a multi-file product, such as 'gmf.gra.2021042200*.grb2', can be repeated")
+	flagSet.StringVar(&pc.mainOptions.checksum, "checksum", common.ChecksumMD5,
+		fmt.Sprintf("checksum algorithm of files: %s, %s or %s",
+			common.ChecksumMD5, common.ChecksumSHA256, common.ChecksumNone))
+
+	flagSet.StringVar(&pc.mainOptions.fromGrib2, "from-grib2", "",
+		"GRIB2 file to fill --start-time, --forecast-time and --number, which are checked if they are set")
+
 	flagSet.BoolVar(&pc.mainOptions.help, "help", false, "print usage")
 
 	flagSet.SortFlags = false
@@ -152,77 +221,119 @@ func (pc *productionCommand) fillProductionInfo() {
 	}
 }
 
-func (pc *productionCommand) fillProductionEventStatus() {
-	status := common.ToEventStatus(pc.mainOptions.status)
+func (pc *productionCommand) fillProductionEventStatus() error {
+	status, err := common.ParseEventStatus(pc.mainOptions.status)
+	if err != nil {
+		return err
+	}
+	event, err := common.ParseProductionEvent(pc.mainOptions.event)
+	if err != nil {
+		return err
+	}
+	err = pc.mainOptions.eventDetail.Validate(event)
+	if err != nil {
+		return err
+	}
 	pc.ProductionEventStatus = common.ProductionEventStatus{
-		Event:  common.ProductionEvent(pc.mainOptions.event),
-		Status: status,
+		Event:                 event,
+		Status:                status,
+		ProductionEventDetail: pc.mainOptions.eventDetail,
 	}
+	return nil
 }
 
-func (pc *productionCommand) createProductionData(args []string) (interface{}, error) {
-	var data interface{}
-	var err error
-	switch pc.ProductionInfo.Stream {
-	case common.ProductionStreamOperation:
-		data, err = pc.getOperationData(args)
-		break
-	case common.ProductionStreamEPS:
-		data, err = pc.getEpsData(args)
-		break
-	default:
-		err = fmt.Errorf("stream type is not supported: %s", pc.ProductionInfo.Stream)
-	}
-
-	return data, err
-}
+// create production data using generator of the stream, one data for each forecast time
+// (and member number for eps) in options.
+func (pc *productionCommand) createProductionData(args []string) ([]interface{}, error) {
+	entry, err := findProductionStream(pc.ProductionInfo.Stream)
+	if err != nil {
+		return nil, err
+	}
 
-func (pc *productionCommand) getOperationData(
-	args []string,
-) (common.OperationProductionData, error) {
-	generator := OperationPropertiesGenerator{}
-	err := generator.parseOptions(args)
+	generator := entry.newGenerator()
+	if pc.mainOptions.fromGrib2 != "" {
+		args, err = applyGrib2Values(args, pc.mainOptions.fromGrib2, generator.generateFlags())
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = generator.parseOptions(args)
 	if err != nil {
-		return common.OperationProductionData{}, err
+		return nil, err
 	}
 
-	data := common.OperationProductionData{
-		ProductionInfo:                pc.ProductionInfo,
-		OperationProductionProperties: generator.OperationProductionProperties,
-		ProductionEventStatus:         pc.ProductionEventStatus,
+	dataList := generator.createData(pc.ProductionInfo, pc.ProductionEventStatus, common.ProductionFiles{})
+	if len(pc.mainOptions.files) == 0 && len(pc.mainOptions.fileGlobs) == 0 {
+		return dataList, nil
 	}
-	return data, nil
-}
 
-func (pc *productionCommand) getEpsData(
-	args []string,
-) (common.EpsProductionData, error) {
-	generator := EpsPropertiesGenerator{}
-	err := generator.parseOptions(args)
+	// check message count before computing checksums of files.
+	if len(dataList) > 1 {
+		return nil, fmt.Errorf("files can only be set for one message, but got %d messages", len(dataList))
+	}
+	files, err := pc.getProductionFiles()
 	if err != nil {
-		return common.EpsProductionData{}, err
+		return nil, err
 	}
+	return generator.createData(pc.ProductionInfo, pc.ProductionEventStatus, files), nil
+}
 
-	data := common.EpsProductionData{
-		ProductionInfo:          pc.ProductionInfo,
-		EpsProductionProperties: generator.EpsProductionProperties,
-		ProductionEventStatus:   pc.ProductionEventStatus,
+// get metadata of files set by --file and --file-glob.
+func (pc *productionCommand) getProductionFiles() (common.ProductionFiles, error) {
+	files, err := common.GetProductionFiles(
+		pc.mainOptions.files, pc.mainOptions.fileGlobs, pc.mainOptions.checksum)
+	if err != nil {
+		return common.ProductionFiles{}, fmt.Errorf("get production files has error: %v", err)
 	}
-	return data, nil
+	return common.ProductionFiles{Files: files}, nil
 }
 
-func (pc *productionCommand) sendProductionMessage(data interface{}) error {
-	message := common.EventMessage{
-		App:  appName,
-		Type: ProductionMessageType,
-		Time: time.Now(),
-		Data: data,
+// check data with product catalogue if it is set.
+// In warn mode, problems are printed and messages are still sent.
+func (pc *productionCommand) checkCatalogue(dataList []interface{}) error {
+	if pc.mainOptions.catalogue == "" {
+		return nil
+	}
+	catalogue, err := common.LoadProductCatalogue(pc.mainOptions.catalogue)
+	if err != nil {
+		return err
 	}
 
+	for _, data := range dataList {
+		err = catalogue.ValidateData(data)
+		if err == nil {
+			continue
+		}
+		if pc.mainOptions.catalogueMode == common.CatalogueModeStrict {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"component": "production",
+			"event":     "catalogue",
+		}).Warnf("%v", err)
+	}
+	return nil
+}
+
+// send one message for each data. Messages of ranges are sent in one batch using one connection.
+func (pc *productionCommand) sendProductionMessages(dataList []interface{}) error {
 	pc.targetParser.option.routeKeyName = fmt.Sprintf(
 		"%s.production.%s", pc.ProductionInfo.System, pc.ProductionInfo.Type)
 
-	return sendEventMessageToTarget(pc.targetParser.option, message)
+	var messages []common.EventMessage
+	for _, data := range dataList {
+		messages = append(messages, common.EventMessage{
+			App:  appName,
+			Type: ProductionMessageType,
+			Time: time.Now(),
+			Data: data,
+		})
+	}
+
+	if len(messages) == 1 {
+		return sendEventMessageToTarget(pc.targetParser.option, messages[0])
+	}
+	return sendEventMessagesToTarget(pc.targetParser.option, messages)
 }
 
 func (pc *productionCommand) printHelp() {
@@ -240,29 +351,14 @@ func (pc *productionCommand) printHelp() {
 	fmt.Fprintf(helpOutput, "Target Flags:\n")
 	targetFlags.PrintDefaults()
 
-	operationProductionDescription := `
-Operation Production
-    --production-stream=oper
-	Use stream oper. Systems include grapes_gfs_gmf, grapes_meso_3km and so on.`
-
-	fmt.Fprintf(helpOutput, "%s\n", operationProductionDescription)
-
-	operGenerator := OperationPropertiesGenerator{}
-	operFlags := operGenerator.generateFlags()
-	operFlags.SetOutput(helpOutput)
-	fmt.Fprintf(helpOutput, "\tFlags:\n")
-	operFlags.PrintDefaults()
+	for _, entry := range productionStreams {
+		fmt.Fprintf(helpOutput, "\n%s\n", entry.title)
+		fmt.Fprintf(helpOutput, "\t--production-stream=%s\n", entry.stream)
+		fmt.Fprintf(helpOutput, "\t%s\n", entry.description)
 
-	epsProductionDescription := `
-Eps Production
-	--production-stream=eps
-	Use stream eps. Systems include grapes_geps and grapes_reps`
-
-	fmt.Fprintf(helpOutput, "%s\n", epsProductionDescription)
-
-	epsGenerator := EpsPropertiesGenerator{}
-	epsFlags := epsGenerator.generateFlags()
-	epsFlags.SetOutput(helpOutput)
-	fmt.Fprintf(helpOutput, "\tFlags:\n")
-	epsFlags.PrintDefaults()
+		streamFlags := entry.newGenerator().generateFlags()
+		streamFlags.SetOutput(helpOutput)
+		fmt.Fprintf(helpOutput, "\tFlags:\n")
+		streamFlags.PrintDefaults()
+	}
 }
@@ -9,11 +9,12 @@ import (
 )
 
 type EpsPropertiesGenerator struct {
-	common.EpsProductionProperties
-	options struct {
+	// one properties for each pair of forecast time and member number.
+	Properties []common.EpsProductionProperties
+	options    struct {
 		startTime    string
 		forecastTime string
-		number       int
+		number       string
 	}
 }
 
@@ -25,9 +26,9 @@ func (parser *EpsPropertiesGenerator) generateFlags() *pflag.FlagSet {
 	epsFlagSet.StringVar(&parser.options.startTime, "start-time", "",
 		"start time, YYYYMMDDHH")
 	epsFlagSet.StringVar(&parser.options.forecastTime, "forecast-time", "",
-		"forecast time, FFFh, 0h, 12h, ...")
-	epsFlagSet.IntVar(&parser.options.number, "number", 0,
-		"member number, such as 0, 1, 2, ...")
+		"forecast time, FFFh, 0h, 12h, ..., or list and range, such as 0h,3h,6h, 000h-240h/3h")
+	epsFlagSet.StringVar(&parser.options.number, "number", "0",
+		"member number, such as 0, 1, 2, ..., or list and range, such as 1,3,5, 0-30, 0-30/2")
 	epsFlagSet.SetAnnotation("start-time", commands.RequiredOption, []string{"true"})
 	epsFlagSet.SetAnnotation("forecast-time", commands.RequiredOption, []string{"true"})
 	return epsFlagSet
@@ -49,11 +50,51 @@ func (parser *EpsPropertiesGenerator) parseOptions(args []string) error {
 	if err != nil {
 		return fmt.Errorf("parse start time %s has error: %v", parser.options.startTime, err)
 	}
-	parser.EpsProductionProperties = common.EpsProductionProperties{
-		StartTime:    startTime,
-		ForecastTime: parser.options.forecastTime,
-		Number:       parser.options.number,
+	forecastTimes, err := expandForecastTimes(parser.options.forecastTime)
+	if err != nil {
+		return err
+	}
+	numbers, err := expandNumbers(parser.options.number)
+	if err != nil {
+		return err
+	}
+	if len(forecastTimes)*len(numbers) > maxExpandCount {
+		return fmt.Errorf("too many messages for forecast times and numbers, max is %d", maxExpandCount)
+	}
+
+	parser.Properties = nil
+	for _, forecastTime := range forecastTimes {
+		forecastHour, validTime, err := common.ComputeForecastFields(startTime, forecastTime)
+		if err != nil {
+			return err
+		}
+		for _, number := range numbers {
+			parser.Properties = append(parser.Properties, common.EpsProductionProperties{
+				StartTime:    startTime,
+				ForecastTime: forecastTime,
+				ForecastHour: forecastHour,
+				ValidTime:    validTime,
+				Number:       number,
+			})
+		}
 	}
 
 	return nil
 }
+
+func (parser *EpsPropertiesGenerator) createData(
+	info common.ProductionInfo,
+	status common.ProductionEventStatus,
+	files common.ProductionFiles,
+) []interface{} {
+	var dataList []interface{}
+	for _, properties := range parser.Properties {
+		dataList = append(dataList, common.EpsProductionData{
+			ProductionInfo:          info,
+			EpsProductionProperties: properties,
+			ProductionEventStatus:   status,
+			ProductionFiles:         files,
+		})
+	}
+	return dataList
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/nwpc-oper/nwpc-message-client/common/grib2"
+	"github.com/spf13/pflag"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// values of production properties read from a GRIB2 file.
+type grib2Values struct {
+	startTime    time.Time
+	forecastTime time.Duration
+	number       *int
+}
+
+// read values from fields in GRIB2 file. All fields should have the same values.
+func readGrib2Values(path string) (grib2Values, error) {
+	fields, err := grib2.ReadFile(path)
+	if err != nil {
+		return grib2Values{}, err
+	}
+	if len(fields) == 0 {
+		return grib2Values{}, fmt.Errorf("no supported field is found in grib2 file: %s", path)
+	}
+
+	var values grib2Values
+	for index, field := range fields {
+		forecastTime, err := field.Forecast()
+		if err != nil {
+			return grib2Values{}, fmt.Errorf("field %d in %s: %v", index+1, path, err)
+		}
+		if index == 0 {
+			values.startTime = field.ReferenceTime
+			values.forecastTime = forecastTime
+		} else if !field.ReferenceTime.Equal(values.startTime) || forecastTime != values.forecastTime {
+			return grib2Values{}, fmt.Errorf(
+				"fields in %s have different reference times or forecast times: %s +%v, %s +%v",
+				path, values.startTime.Format(time.RFC3339), values.forecastTime,
+				field.ReferenceTime.Format(time.RFC3339), forecastTime)
+		}
+
+		if field.PerturbationNumber == nil {
+			continue
+		}
+		if values.number != nil && *values.number != *field.PerturbationNumber {
+			return grib2Values{}, fmt.Errorf("fields in %s have different ensemble members: %d, %d",
+				path, *values.number, *field.PerturbationNumber)
+		}
+		number := *field.PerturbationNumber
+		values.number = &number
+	}
+	return values, nil
+}
+
+// fill --start-time, --forecast-time and --number (if stream has it) in args with values in GRIB2 file.
+// Flags already in args are checked against values in file, and mismatches are returned as error.
+func applyGrib2Values(args []string, path string, streamFlags *pflag.FlagSet) ([]string, error) {
+	values, err := readGrib2Values(path)
+	if err != nil {
+		return nil, err
+	}
+	if values.forecastTime%time.Hour != 0 {
+		return nil, fmt.Errorf("forecast time in %s is not whole hours: %v", path, values.forecastTime)
+	}
+
+	err = streamFlags.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("parse options has error: %s", err)
+	}
+
+	fileValues := map[string]string{
+		"start-time":    values.startTime.Format("2006010215"),
+		"forecast-time": fmt.Sprintf("%03dh", int(values.forecastTime/time.Hour)),
+	}
+	if values.number != nil && streamFlags.Lookup("number") != nil {
+		fileValues["number"] = strconv.Itoa(*values.number)
+	}
+
+	var problems []string
+	for _, name := range []string{"start-time", "forecast-time", "number"} {
+		fileValue, found := fileValues[name]
+		if !found {
+			continue
+		}
+		if !streamFlags.Changed(name) {
+			args = append(args, fmt.Sprintf("--%s=%s", name, fileValue))
+			continue
+		}
+		flagValue := streamFlags.Lookup(name).Value.String()
+		if !isSameProductionValue(name, flagValue, fileValue) {
+			problems = append(problems, fmt.Sprintf("--%s is %s but %s in file", name, flagValue, fileValue))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("flags do not match grib2 file %s: %s", path, strings.Join(problems, "; "))
+	}
+	return args, nil
+}
+
+// compare value in flag and value in file. Forecast times are compared by duration, so 0h equals to 000h.
+func isSameProductionValue(name string, flagValue string, fileValue string) bool {
+	if name != "forecast-time" {
+		return flagValue == fileValue
+	}
+	flagDuration, err := common.ParseForecastTime(flagValue)
+	if err != nil {
+		return false
+	}
+	fileDuration, err := common.ParseForecastTime(fileValue)
+	return err == nil && flagDuration == fileDuration
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/commands"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/spf13/pflag"
+	"time"
+)
+
+type HindcastPropertiesGenerator struct {
+	// one properties for each forecast time.
+	Properties []common.HindcastProductionProperties
+	options    struct {
+		startTime     string
+		referenceDate string
+		forecastTime  string
+	}
+}
+
+func (parser *HindcastPropertiesGenerator) generateFlags() *pflag.FlagSet {
+	hindcastFlagSet := pflag.NewFlagSet("hindcast", pflag.ContinueOnError)
+	hindcastFlagSet.SortFlags = false
+	hindcastFlagSet.ParseErrorsWhitelist.UnknownFlags = true
+
+	hindcastFlagSet.StringVar(&parser.options.startTime, "start-time", "",
+		"start time, YYYYMMDDHH")
+	hindcastFlagSet.StringVar(&parser.options.referenceDate, "reference-date", "",
+		"reference date of the forecast which hindcast is for, YYYYMMDD")
+	hindcastFlagSet.StringVar(&parser.options.forecastTime, "forecast-time", "",
+		"forecast time, FFFh, 0h, 12h, ..., or list and range, such as 0h,3h,6h, 000h-240h/3h")
+	hindcastFlagSet.SetAnnotation("start-time", commands.RequiredOption, []string{"true"})
+	hindcastFlagSet.SetAnnotation("reference-date", commands.RequiredOption, []string{"true"})
+	hindcastFlagSet.SetAnnotation("forecast-time", commands.RequiredOption, []string{"true"})
+	return hindcastFlagSet
+}
+
+func (parser *HindcastPropertiesGenerator) parseOptions(args []string) error {
+	hindcastFlagSet := parser.generateFlags()
+	err := hindcastFlagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse options has error: %s", err)
+	}
+
+	err = commands.CheckRequiredFlags(hindcastFlagSet)
+	if err != nil {
+		return fmt.Errorf("%v", err)
+	}
+
+	startTime, err := time.Parse("2006010215", parser.options.startTime)
+	if err != nil {
+		return fmt.Errorf("parse start time %s has error: %v", parser.options.startTime, err)
+	}
+	referenceDate, err := time.Parse("20060102", parser.options.referenceDate)
+	if err != nil {
+		return fmt.Errorf("parse reference date %s has error: %v", parser.options.referenceDate, err)
+	}
+	forecastTimes, err := expandForecastTimes(parser.options.forecastTime)
+	if err != nil {
+		return err
+	}
+
+	parser.Properties = nil
+	for _, forecastTime := range forecastTimes {
+		forecastHour, validTime, err := common.ComputeForecastFields(startTime, forecastTime)
+		if err != nil {
+			return err
+		}
+		parser.Properties = append(parser.Properties, common.HindcastProductionProperties{
+			StartTime:     startTime,
+			ReferenceDate: referenceDate,
+			ForecastTime:  forecastTime,
+			ForecastHour:  forecastHour,
+			ValidTime:     validTime,
+		})
+	}
+
+	return nil
+}
+
+func (parser *HindcastPropertiesGenerator) createData(
+	info common.ProductionInfo,
+	status common.ProductionEventStatus,
+	files common.ProductionFiles,
+) []interface{} {
+	var dataList []interface{}
+	for _, properties := range parser.Properties {
+		dataList = append(dataList, common.HindcastProductionData{
+			ProductionInfo:               info,
+			HindcastProductionProperties: properties,
+			ProductionEventStatus:        status,
+			ProductionFiles:              files,
+		})
+	}
+	return dataList
+}
@@ -9,8 +9,9 @@ import (
 )
 
 type OperationPropertiesGenerator struct {
-	common.OperationProductionProperties
-	options struct {
+	// one properties for each forecast time.
+	Properties []common.OperationProductionProperties
+	options    struct {
 		startTime    string
 		forecastTime string
 	}
@@ -24,7 +25,7 @@ func (parser *OperationPropertiesGenerator) generateFlags() *pflag.FlagSet {
 	operFlagSet.StringVar(&parser.options.startTime, "start-time", "",
 		"start time, YYYYMMDDHH")
 	operFlagSet.StringVar(&parser.options.forecastTime, "forecast-time", "",
-		"forecast time, FFFh, 0h, 12h, ...")
+		"forecast time, FFFh, 0h, 12h, ..., or list and range, such as 0h,3h,6h, 000h-240h/3h")
 	operFlagSet.SetAnnotation("start-time", commands.RequiredOption, []string{"true"})
 	operFlagSet.SetAnnotation("forecast-time", commands.RequiredOption, []string{"true"})
 	return operFlagSet
@@ -46,10 +47,41 @@ func (parser *OperationPropertiesGenerator) parseOptions(args []string) error {
 	if err != nil {
 		return fmt.Errorf("parse start time %s has error: %v", parser.options.startTime, err)
 	}
-	parser.OperationProductionProperties = common.OperationProductionProperties{
-		StartTime:    startTime,
-		ForecastTime: parser.options.forecastTime,
+	forecastTimes, err := expandForecastTimes(parser.options.forecastTime)
+	if err != nil {
+		return err
+	}
+
+	parser.Properties = nil
+	for _, forecastTime := range forecastTimes {
+		forecastHour, validTime, err := common.ComputeForecastFields(startTime, forecastTime)
+		if err != nil {
+			return err
+		}
+		parser.Properties = append(parser.Properties, common.OperationProductionProperties{
+			StartTime:    startTime,
+			ForecastTime: forecastTime,
+			ForecastHour: forecastHour,
+			ValidTime:    validTime,
+		})
 	}
 
 	return nil
 }
+
+func (parser *OperationPropertiesGenerator) createData(
+	info common.ProductionInfo,
+	status common.ProductionEventStatus,
+	files common.ProductionFiles,
+) []interface{} {
+	var dataList []interface{}
+	for _, properties := range parser.Properties {
+		dataList = append(dataList, common.OperationProductionData{
+			ProductionInfo:                info,
+			OperationProductionProperties: properties,
+			ProductionEventStatus:         status,
+			ProductionFiles:               files,
+		})
+	}
+	return dataList
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// max count of values expanded from one option, which avoids sending huge batches by mistake.
+const maxExpandCount = 10000
+
+// expand forecast time option into a list of forecast times. Option is a comma separated list
+// of forecast times or ranges, such as
+//
+//	000h
+//	000h-240h/3h
+//	0h,3h,6h
+//	000h-072h/3h,078h-240h/6h
+//
+// Values in a range have the same unit and are zero-padded to width of range start.
+func expandForecastTimes(option string) ([]string, error) {
+	var forecastTimes []string
+	for _, item := range strings.Split(option, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("empty forecast time in %s", option)
+		}
+		if !strings.Contains(item, "-") {
+			forecastTimes = append(forecastTimes, item)
+			continue
+		}
+
+		values, err := expandForecastTimeRange(item)
+		if err != nil {
+			return nil, err
+		}
+		forecastTimes = append(forecastTimes, values...)
+		if len(forecastTimes) > maxExpandCount {
+			return nil, fmt.Errorf("too many forecast times in %s, max is %d", option, maxExpandCount)
+		}
+	}
+	return forecastTimes, nil
+}
+
+// expand range start-end/step, such as 000h-240h/3h. Step is 1 if it is not set.
+func expandForecastTimeRange(item string) ([]string, error) {
+	rangePart := item
+	stepPart := ""
+	if index := strings.Index(item, "/"); index != -1 {
+		rangePart = item[:index]
+		stepPart = item[index+1:]
+	}
+	tokens := strings.Split(rangePart, "-")
+	if len(tokens) != 2 {
+		return nil, fmt.Errorf("forecast time range should be start-end/step: %s", item)
+	}
+
+	start, width, unit, err := parseForecastTimeValue(tokens[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse range %s has error: %v", item, err)
+	}
+	end, _, endUnit, err := parseForecastTimeValue(tokens[1])
+	if err != nil {
+		return nil, fmt.Errorf("parse range %s has error: %v", item, err)
+	}
+	step := 1
+	stepUnit := unit
+	if stepPart != "" {
+		step, _, stepUnit, err = parseForecastTimeValue(stepPart)
+		if err != nil {
+			return nil, fmt.Errorf("parse range %s has error: %v", item, err)
+		}
+	}
+
+	if endUnit != unit || stepUnit != unit {
+		return nil, fmt.Errorf("units in forecast time range should be the same: %s", item)
+	}
+	if err = checkRange(start, end, step); err != nil {
+		return nil, fmt.Errorf("forecast time range %s is invalid: %v", item, err)
+	}
+
+	var values []string
+	for value := start; value <= end; value += step {
+		values = append(values, fmt.Sprintf("%0*d%s", width, value, unit))
+	}
+	return values, nil
+}
+
+// parse forecast time such as 012h, returns value, width of digits and unit.
+func parseForecastTimeValue(token string) (int, int, string, error) {
+	token = strings.TrimSpace(token)
+	width := strings.IndexFunc(token, func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+	if width == -1 {
+		width = len(token)
+	}
+	if width == 0 {
+		return 0, 0, "", fmt.Errorf("forecast time should start with digits: %s", token)
+	}
+	value, err := strconv.Atoi(token[:width])
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("parse forecast time %s has error: %v", token, err)
+	}
+	return value, width, token[width:], nil
+}
+
+// expand member number option into a list of numbers, such as
+//
+//	0
+//	0-30
+//	1,3,5
+//	0-30/2
+func expandNumbers(option string) ([]int, error) {
+	var numbers []int
+	for _, item := range strings.Split(option, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("empty number in %s", option)
+		}
+		values, err := expandNumberRange(item)
+		if err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, values...)
+		if len(numbers) > maxExpandCount {
+			return nil, fmt.Errorf("too many numbers in %s, max is %d", option, maxExpandCount)
+		}
+	}
+	return numbers, nil
+}
+
+func expandNumberRange(item string) ([]int, error) {
+	rangePart := item
+	step := 1
+	var err error
+	if index := strings.Index(item, "/"); index != -1 {
+		rangePart = item[:index]
+		step, err = strconv.Atoi(item[index+1:])
+		if err != nil {
+			return nil, fmt.Errorf("parse step of number range %s has error: %v", item, err)
+		}
+	}
+
+	tokens := strings.Split(rangePart, "-")
+	if len(tokens) > 2 {
+		return nil, fmt.Errorf("number range should be start-end/step: %s", item)
+	}
+	start, err := strconv.Atoi(tokens[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse number %s has error: %v", item, err)
+	}
+	end := start
+	if len(tokens) == 2 {
+		end, err = strconv.Atoi(tokens[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse number %s has error: %v", item, err)
+		}
+	}
+	if err = checkRange(start, end, step); err != nil {
+		return nil, fmt.Errorf("number range %s is invalid: %v", item, err)
+	}
+
+	var values []int
+	for value := start; value <= end; value += step {
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func checkRange(start int, end int, step int) error {
+	if step <= 0 {
+		return fmt.Errorf("step should be positive: %d", step)
+	}
+	if end < start {
+		return fmt.Errorf("end %d is less than start %d", end, start)
+	}
+	if (end-start)/step+1 > maxExpandCount {
+		return fmt.Errorf("too many values, max is %d", maxExpandCount)
+	}
+	return nil
+}
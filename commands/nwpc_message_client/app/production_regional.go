@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/commands"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/spf13/pflag"
+	"time"
+)
+
+type RegionalPropertiesGenerator struct {
+	// one properties for each forecast time.
+	Properties []common.RegionalProductionProperties
+	options    struct {
+		startTime    string
+		forecastTime string
+		domainID     string
+	}
+}
+
+func (parser *RegionalPropertiesGenerator) generateFlags() *pflag.FlagSet {
+	regionalFlagSet := pflag.NewFlagSet("regional", pflag.ContinueOnError)
+	regionalFlagSet.SortFlags = false
+	regionalFlagSet.ParseErrorsWhitelist.UnknownFlags = true
+
+	regionalFlagSet.StringVar(&parser.options.startTime, "start-time", "",
+		"start time, YYYYMMDDHH")
+	regionalFlagSet.StringVar(&parser.options.forecastTime, "forecast-time", "",
+		"forecast time, FFFh, 0h, 12h, ..., or list and range, such as 0h,3h,6h, 000h-240h/3h")
+	regionalFlagSet.StringVar(&parser.options.domainID, "domain-id", "",
+		"id of nested domain, such as d01, d02")
+	regionalFlagSet.SetAnnotation("start-time", commands.RequiredOption, []string{"true"})
+	regionalFlagSet.SetAnnotation("forecast-time", commands.RequiredOption, []string{"true"})
+	regionalFlagSet.SetAnnotation("domain-id", commands.RequiredOption, []string{"true"})
+	return regionalFlagSet
+}
+
+func (parser *RegionalPropertiesGenerator) parseOptions(args []string) error {
+	regionalFlagSet := parser.generateFlags()
+	err := regionalFlagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse options has error: %s", err)
+	}
+
+	err = commands.CheckRequiredFlags(regionalFlagSet)
+	if err != nil {
+		return fmt.Errorf("%v", err)
+	}
+
+	startTime, err := time.Parse("2006010215", parser.options.startTime)
+	if err != nil {
+		return fmt.Errorf("parse start time %s has error: %v", parser.options.startTime, err)
+	}
+	forecastTimes, err := expandForecastTimes(parser.options.forecastTime)
+	if err != nil {
+		return err
+	}
+
+	parser.Properties = nil
+	for _, forecastTime := range forecastTimes {
+		forecastHour, validTime, err := common.ComputeForecastFields(startTime, forecastTime)
+		if err != nil {
+			return err
+		}
+		parser.Properties = append(parser.Properties, common.RegionalProductionProperties{
+			StartTime:    startTime,
+			ForecastTime: forecastTime,
+			ForecastHour: forecastHour,
+			ValidTime:    validTime,
+			DomainID:     parser.options.domainID,
+		})
+	}
+
+	return nil
+}
+
+func (parser *RegionalPropertiesGenerator) createData(
+	info common.ProductionInfo,
+	status common.ProductionEventStatus,
+	files common.ProductionFiles,
+) []interface{} {
+	var dataList []interface{}
+	for _, properties := range parser.Properties {
+		dataList = append(dataList, common.RegionalProductionData{
+			ProductionInfo:               info,
+			RegionalProductionProperties: properties,
+			ProductionEventStatus:        status,
+			ProductionFiles:              files,
+		})
+	}
+	return dataList
+}
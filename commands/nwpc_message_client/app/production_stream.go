@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/spf13/pflag"
+	"strings"
+)
+
+// productionPropertiesGenerator creates production data of one stream from its own flags.
+type productionPropertiesGenerator interface {
+	generateFlags() *pflag.FlagSet
+	parseOptions(args []string) error
+
+	// create one production data for each properties parsed from options.
+	createData(
+		info common.ProductionInfo,
+		status common.ProductionEventStatus,
+		files common.ProductionFiles,
+	) []interface{}
+}
+
+// productionStreamEntry declares a production stream supported by production command.
+type productionStreamEntry struct {
+	stream       common.ProductionStream
+	title        string
+	description  string
+	newGenerator func() productionPropertiesGenerator
+}
+
+// registry of production streams. To support a new stream, add properties and data in common package,
+// implement a productionPropertiesGenerator and add an entry here.
+var productionStreams = []productionStreamEntry{
+	{
+		stream:      common.ProductionStreamOperation,
+		title:       "Operation Production",
+		description: "Use stream oper. Systems include grapes_gfs_gmf, grapes_meso_3km and so on.",
+		newGenerator: func() productionPropertiesGenerator {
+			return &OperationPropertiesGenerator{}
+		},
+	},
+	{
+		stream:      common.ProductionStreamEPS,
+		title:       "Eps Production",
+		description: "Use stream eps. Systems include grapes_geps and grapes_reps",
+		newGenerator: func() productionPropertiesGenerator {
+			return &EpsPropertiesGenerator{}
+		},
+	},
+	{
+		stream:      common.ProductionStreamHindcast,
+		title:       "Hindcast Production",
+		description: "Use stream hindcast. Hindcast runs past start times for the forecast of a reference date.",
+		newGenerator: func() productionPropertiesGenerator {
+			return &HindcastPropertiesGenerator{}
+		},
+	},
+	{
+		stream:      common.ProductionStreamRegional,
+		title:       "Regional Production",
+		description: "Use stream regional. Regional nested systems set domain id for each nested domain.",
+		newGenerator: func() productionPropertiesGenerator {
+			return &RegionalPropertiesGenerator{}
+		},
+	},
+}
+
+func findProductionStream(stream common.ProductionStream) (*productionStreamEntry, error) {
+	var names []string
+	for index := range productionStreams {
+		entry := &productionStreams[index]
+		if entry.stream == stream {
+			return entry, nil
+		}
+		names = append(names, string(entry.stream))
+	}
+	return nil, fmt.Errorf("stream type is not supported: %s, valid streams: %s",
+		stream, strings.Join(names, ", "))
+}
+
+func productionStreamNames() string {
+	var names []string
+	for _, entry := range productionStreams {
+		names = append(names, string(entry.stream))
+	}
+	return strings.Join(names, ", ")
+}
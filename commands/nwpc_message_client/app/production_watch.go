@@ -0,0 +1,424 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const productionWatchDescription = `
+Watch a directory and send a storage message for each new file.
+
+  nwpc_message_client production watch --dir <dir> --pattern <regex> [production flags]
+
+Files are found by polling. A file is announced when its size and modify time have not changed
+for --stable-time. Named groups in --pattern are used as production flags, such as
+
+  --pattern 'gmf\.gra\.(?P<start_time>\d{10})(?P<forecast_time>\d{3})\.grb2'
+
+sets --start-time and --forecast-time. Forecast time with only digits is in hours.
+Other production flags, such as --system and --production-type, are set in command line
+and are used for all files. --event is storage if it is not set. File is added by --file.
+
+Announced files are saved into --state-file, so they are not announced again after restart
+unless their size or modify time changes. Files whose messages can't be created, such as files
+with invalid fields in names or rejected by catalogue, are also saved with errors and are not tried
+again until they change. Only failed deliveries are tried in next scans.
+`
+
+type productionWatchCommand struct {
+	BaseCommand
+
+	mainOptions struct {
+		dir        string
+		pattern    string
+		stableTime time.Duration
+		interval   time.Duration
+		stateFile  string
+		help       bool
+	}
+
+	patternRegexp *regexp.Regexp
+	state         watchState
+	pendingFiles  map[string]*pendingFile
+}
+
+// file which is waiting for its size to be stable.
+type pendingFile struct {
+	size        int64
+	modifyTime  time.Time
+	stableSince time.Time
+
+	// messages created for stable file, kept to send again if delivery fails.
+	production *productionCommand
+	dataList   []interface{}
+}
+
+// watchState records announced files and files whose messages can't be created, saved in state file.
+type watchState struct {
+	Files map[string]announcedFile `json:"files"`
+}
+
+type announcedFile struct {
+	Size         int64     `json:"size"`
+	ModifyTime   time.Time `json:"modify_time"`
+	AnnounceTime time.Time `json:"announce_time"`
+	Error        string    `json:"error,omitempty"`
+}
+
+func newProductionWatchCommand() *productionWatchCommand {
+	wc := &productionWatchCommand{
+		pendingFiles: make(map[string]*pendingFile),
+	}
+	watchCmd := &cobra.Command{
+		Use:                "watch",
+		Short:              "watch a directory and send storage messages for new files",
+		Long:               productionWatchDescription,
+		RunE:               wc.runCommand,
+		DisableFlagParsing: true,
+	}
+	watchCmd.SetUsageFunc(func(*cobra.Command) error {
+		wc.printHelp()
+		return nil
+	})
+	watchCmd.SetHelpFunc(func(*cobra.Command, []string) {
+		wc.printHelp()
+	})
+
+	wc.cmd = watchCmd
+	return wc
+}
+
+func (wc *productionWatchCommand) runCommand(cmd *cobra.Command, args []string) error {
+	// generating flags resets main options, so production args are got before parsing main options.
+	productionArgs := removeWatchFlags(args, wc.generateMainFlags())
+	if !hasFlag(productionArgs, "event") {
+		productionArgs = append(productionArgs, "--event=storage")
+	}
+
+	err := wc.parseMainOptions(args)
+	if wc.mainOptions.help {
+		wc.printHelp()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("parse main options has error: %v", err)
+	}
+
+	// check target options once, so that errors of files are only from their messages.
+	err = newProductionCommand().targetParser.parseCommandTargetOptions(productionArgs)
+	if err != nil {
+		return fmt.Errorf("parse target options has error: %v", err)
+	}
+
+	wc.state, err = loadWatchState(wc.mainOptions.stateFile)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"component": "watch",
+		"event":     "start",
+	}).Infof("watch %s for %s", wc.mainOptions.dir, wc.mainOptions.pattern)
+
+	for {
+		wc.scan(productionArgs)
+		time.Sleep(wc.mainOptions.interval)
+	}
+}
+
+// scan directory once, and send messages for files whose sizes are stable.
+func (wc *productionWatchCommand) scan(productionArgs []string) {
+	entries, err := ioutil.ReadDir(wc.mainOptions.dir)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "watch",
+			"event":     "scan",
+		}).Errorf("read directory has error: %v", err)
+		return
+	}
+
+	now := time.Now()
+	found := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !wc.patternRegexp.MatchString(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(wc.mainOptions.dir, entry.Name())
+		found[path] = true
+
+		if announced, ok := wc.state.Files[path]; ok &&
+			announced.Size == entry.Size() && announced.ModifyTime.Equal(entry.ModTime()) {
+			continue
+		}
+
+		pending, ok := wc.pendingFiles[path]
+		if !ok || pending.size != entry.Size() || !pending.modifyTime.Equal(entry.ModTime()) {
+			wc.pendingFiles[path] = &pendingFile{
+				size:        entry.Size(),
+				modifyTime:  entry.ModTime(),
+				stableSince: now,
+			}
+			continue
+		}
+		if now.Sub(pending.stableSince) < wc.mainOptions.stableTime {
+			continue
+		}
+
+		if pending.dataList == nil {
+			pending.production, pending.dataList, err = wc.createMessages(path, entry.Name(), productionArgs)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"component": "watch",
+					"event":     "create",
+				}).Errorf("create message for %s has error, file is skipped until it changes: %v", path, err)
+				wc.finishFile(path, pending, err)
+				continue
+			}
+		}
+
+		err = pending.production.sendProductionMessages(pending.dataList)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"component": "watch",
+				"event":     "send",
+			}).Errorf("send message for %s has error, will try in next scan: %v", path, err)
+			continue
+		}
+		wc.finishFile(path, pending, nil)
+	}
+
+	// forget removed files.
+	for path := range wc.pendingFiles {
+		if !found[path] {
+			delete(wc.pendingFiles, path)
+		}
+	}
+	removed := false
+	for path := range wc.state.Files {
+		if !found[path] {
+			delete(wc.state.Files, path)
+			removed = true
+		}
+	}
+	if removed {
+		wc.saveState()
+	}
+}
+
+// create messages for a stable file using production command, with flags from named groups in pattern.
+func (wc *productionWatchCommand) createMessages(
+	path string,
+	name string,
+	productionArgs []string,
+) (*productionCommand, []interface{}, error) {
+	args := append([]string{}, productionArgs...)
+	args = append(args, wc.getFlagsFromName(name)...)
+	args = append(args, "--file="+path)
+
+	log.WithFields(log.Fields{
+		"component": "watch",
+		"event":     "create",
+	}).Infof("file is stable: %s", path)
+	pc := newProductionCommand()
+	err := pc.parseMainOptions(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse main options has error: %v", err)
+	}
+	err = pc.targetParser.parseCommandTargetOptions(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parser target options has error: %v", err)
+	}
+	dataList, err := pc.createCheckedProductionData(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pc, dataList, nil
+}
+
+// record announced file, or file whose messages can't be created, into state.
+func (wc *productionWatchCommand) finishFile(path string, pending *pendingFile, err error) {
+	delete(wc.pendingFiles, path)
+	file := announcedFile{
+		Size:         pending.size,
+		ModifyTime:   pending.modifyTime,
+		AnnounceTime: time.Now(),
+	}
+	if err != nil {
+		file.Error = err.Error()
+	}
+	wc.state.Files[path] = file
+	wc.saveState()
+}
+
+func (wc *productionWatchCommand) saveState() {
+	err := wc.state.save(wc.mainOptions.stateFile)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "watch",
+			"event":     "state",
+		}).Errorf("%v", err)
+	}
+}
+
+// named groups, such as start_time, are converted into production flags, such as --start-time.
+func (wc *productionWatchCommand) getFlagsFromName(name string) []string {
+	match := wc.patternRegexp.FindStringSubmatch(name)
+	var flags []string
+	for index, groupName := range wc.patternRegexp.SubexpNames() {
+		if groupName == "" || match[index] == "" {
+			continue
+		}
+		value := match[index]
+		if groupName == "forecast_time" && isDigits(value) {
+			value += "h"
+		}
+		flags = append(flags, fmt.Sprintf("--%s=%s", strings.ReplaceAll(groupName, "_", "-"), value))
+	}
+	return flags
+}
+
+func (wc *productionWatchCommand) generateMainFlags() *pflag.FlagSet {
+	mainFlagSet := pflag.NewFlagSet("watch", pflag.ContinueOnError)
+	mainFlagSet.SortFlags = false
+	mainFlagSet.ParseErrorsWhitelist.UnknownFlags = true
+
+	mainFlagSet.StringVar(&wc.mainOptions.dir, "dir", "", "directory to watch")
+	mainFlagSet.StringVar(&wc.mainOptions.pattern, "pattern", "",
+		"regular expression of file name, named groups are used as production flags")
+	mainFlagSet.DurationVar(&wc.mainOptions.stableTime, "stable-time", 30*time.Second,
+		"file is announced after its size is not changed for this duration")
+	mainFlagSet.DurationVar(&wc.mainOptions.interval, "interval", 10*time.Second,
+		"interval to scan directory")
+	mainFlagSet.StringVar(&wc.mainOptions.stateFile, "state-file", "",
+		"file to save announced files, so that they are not announced again after restart")
+	mainFlagSet.BoolVar(&wc.mainOptions.help, "help", false, "print usage")
+	return mainFlagSet
+}
+
+func (wc *productionWatchCommand) parseMainOptions(args []string) error {
+	mainFlagSet := wc.generateMainFlags()
+	err := mainFlagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse options has error: %s", err)
+	}
+	if wc.mainOptions.help {
+		return nil
+	}
+
+	if wc.mainOptions.dir == "" || wc.mainOptions.pattern == "" {
+		return fmt.Errorf("--dir and --pattern are required")
+	}
+	if wc.mainOptions.interval <= 0 {
+		return fmt.Errorf("--interval should be positive: %v", wc.mainOptions.interval)
+	}
+	wc.patternRegexp, err = regexp.Compile(wc.mainOptions.pattern)
+	if err != nil {
+		return fmt.Errorf("compile pattern has error: %v", err)
+	}
+	return nil
+}
+
+func (wc *productionWatchCommand) printHelp() {
+	helpOutput := os.Stdout
+	fmt.Fprintf(helpOutput, "%s\n", productionWatchDescription)
+
+	mainFlags := wc.generateMainFlags()
+	mainFlags.SetOutput(helpOutput)
+	fmt.Fprintf(helpOutput, "Watch Flags:\n")
+	mainFlags.PrintDefaults()
+
+	fmt.Fprintf(helpOutput, "\nPlease run `%s production --help` for production flags.\n", appCommand)
+}
+
+// load state from file. Missing file is an empty state.
+func loadWatchState(path string) (watchState, error) {
+	state := watchState{Files: make(map[string]announcedFile)}
+	if path == "" {
+		return state, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("read state file has error: %v", err)
+	}
+	err = json.Unmarshal(content, &state)
+	if err != nil {
+		return state, fmt.Errorf("parse state file %s has error: %v", path, err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]announcedFile)
+	}
+	return state, nil
+}
+
+// save state into a temporary file and rename it, so that state file is not broken if process is killed.
+func (s *watchState) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state has error: %v", err)
+	}
+	tempPath := path + ".tmp"
+	err = ioutil.WriteFile(tempPath, content, 0644)
+	if err != nil {
+		return fmt.Errorf("write state file has error: %v", err)
+	}
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		return fmt.Errorf("rename state file has error: %v", err)
+	}
+	return nil
+}
+
+// remove flags of watch command and their values from args.
+func removeWatchFlags(args []string, flagSet *pflag.FlagSet) []string {
+	var result []string
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+		if !strings.HasPrefix(arg, "--") {
+			result = append(result, arg)
+			continue
+		}
+		tokens := strings.SplitN(arg[2:], "=", 2)
+		flag := flagSet.Lookup(tokens[0])
+		if flag == nil {
+			result = append(result, arg)
+			continue
+		}
+		if len(tokens) == 1 && flag.Value.Type() != "bool" {
+			index++
+		}
+	}
+	return result
+}
+
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
@@ -37,6 +37,8 @@ func (b *commandsBuilder) addAll() *commandsBuilder {
 		newMessageCommand(),
 		newBrokerCommand(),
 		newLogCommand(),
+		newJobCommand(),
+		newPredictCommand(),
 	)
 	return b
 }
@@ -31,6 +31,8 @@ type ecflowClientCommand struct {
 	bulkSize    int
 
 	isDebug bool
+
+	tracingOptions
 }
 
 func (c *ecflowClientCommand) consumerEcflowClient(cmd *cobra.Command, args []string) error {
@@ -46,12 +48,19 @@ func (c *ecflowClientCommand) consumerEcflowClient(cmd *cobra.Command, args []st
 		Queue:    c.rabbitmqQueueName,
 	}
 
+	tracer, err := c.createTracer("nwpc-message-consumer-ecflow-client")
+	if err != nil {
+		return err
+	}
+	defer tracer.Close()
+
 	var currentConsumer consumer.Consumer = nil
 	if c.consumerType == string(printerConsumerType) {
 		currentConsumer = createPrinterConsumer(
 			source,
 			c.workerCount,
-			c.isDebug)
+			c.isDebug,
+			tracer)
 	} else if c.consumerType == string(elasticsearchConsumerType) {
 		target := consumer.ElasticSearchTarget{
 			Server: c.elasticServer,
@@ -61,7 +70,8 @@ func (c *ecflowClientCommand) consumerEcflowClient(cmd *cobra.Command, args []st
 			target,
 			c.workerCount,
 			c.bulkSize,
-			c.isDebug)
+			c.isDebug,
+			tracer)
 	}
 
 	if currentConsumer == nil {
@@ -69,7 +79,7 @@ func (c *ecflowClientCommand) consumerEcflowClient(cmd *cobra.Command, args []st
 		return fmt.Errorf("consumer type is not supported: %s", c.consumerType)
 	}
 
-	err := currentConsumer.ConsumeMessages()
+	err = currentConsumer.ConsumeMessages()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"component": "ecflow-client",
@@ -142,6 +152,8 @@ func newEcflowClientCommand() *ecflowClientCommand {
 		"debug mode",
 	)
 
+	ec.addTracingFlags(ecflowClientCmd.Flags())
+
 	ecflowClientCmd.MarkFlagRequired("rabbitmq-server")
 	ecflowClientCmd.MarkFlagRequired("rabbitmq-queue-name")
 	ecflowClientCmd.MarkFlagRequired("elastic-server")
@@ -13,6 +13,8 @@ type ecflowClientKafkaCommand struct {
 	topic         string
 
 	isDebug bool
+
+	tracingOptions
 }
 
 func (c *ecflowClientKafkaCommand) consumerEcflowClient(cmd *cobra.Command, args []string) error {
@@ -21,6 +23,12 @@ func (c *ecflowClientKafkaCommand) consumerEcflowClient(cmd *cobra.Command, args
 		"event":     "consumer",
 	}).Info("start to consume...")
 
+	tracer, err := c.createTracer("nwpc-message-consumer-ecflow-client-kafka")
+	if err != nil {
+		return err
+	}
+	defer tracer.Close()
+
 	source := consumer.KafkaSource{
 		Brokers: c.brokerServers,
 		Topic:   c.topic,
@@ -28,9 +36,10 @@ func (c *ecflowClientKafkaCommand) consumerEcflowClient(cmd *cobra.Command, args
 
 	currentConsumer := consumer.KafkaPrinterConsumer{
 		Source: source,
+		Tracer: tracer,
 	}
 
-	err := currentConsumer.ConsumeMessages()
+	err = currentConsumer.ConsumeMessages()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"component": "ecflow-client",
@@ -62,6 +71,8 @@ func newEcflowClientKafkaCommand() *ecflowClientKafkaCommand {
 		[]string{},
 		"brokers")
 
+	ec.addTracingFlags(ecflowClientCmd.Flags())
+
 	ec.cmd = ecflowClientCmd
 
 	return ec
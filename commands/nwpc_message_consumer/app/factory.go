@@ -1,6 +1,10 @@
 package app
 
-import "github.com/nwpc-oper/nwpc-message-client/common/consumer"
+import (
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/nwpc-oper/nwpc-message-client/common/consumer"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
+)
 
 type consumerType string
 
@@ -13,11 +17,13 @@ func createPrinterConsumer(
 	source consumer.RabbitMQSource,
 	workerCount int,
 	debug bool,
+	tracer *tracing.Tracer,
 ) *consumer.PrinterConsumer {
 	printerConsumer := &consumer.PrinterConsumer{
 		Source:      source,
 		WorkerCount: workerCount,
 		Debug:       debug,
+		Tracer:      tracer,
 	}
 	return printerConsumer
 }
@@ -28,13 +34,21 @@ func createElasticSearchConsumer(
 	workerCount int,
 	bulkSize int,
 	debug bool,
+	tracer *tracing.Tracer,
+	catalogue *common.ProductCatalogue,
+	catalogueMode string,
+	timelineIndex string,
 ) *consumer.ProductionConsumer {
 	elasticSearchConsumer := &consumer.ProductionConsumer{
-		Source:      source,
-		Target:      target,
-		WorkerCount: workerCount,
-		BulkSize:    bulkSize,
-		Debug:       debug,
+		Source:        source,
+		Target:        target,
+		WorkerCount:   workerCount,
+		BulkSize:      bulkSize,
+		Debug:         debug,
+		Tracer:        tracer,
+		Catalogue:     catalogue,
+		CatalogueMode: catalogueMode,
+		TimelineIndex: timelineIndex,
 	}
 	return elasticSearchConsumer
 }
@@ -45,6 +59,7 @@ func createEcflowClientConsumer(
 	workerCount int,
 	bulkSize int,
 	debug bool,
+	tracer *tracing.Tracer,
 ) *consumer.EcflowClientConsumer {
 	ecConsumer := &consumer.EcflowClientConsumer{
 		Source:      source,
@@ -52,6 +67,7 @@ func createEcflowClientConsumer(
 		WorkerCount: workerCount,
 		BulkSize:    bulkSize,
 		Debug:       debug,
+		Tracer:      tracer,
 	}
 	return ecConsumer
 }
@@ -62,6 +78,7 @@ func createPredictConsumer(
 	workerCount int,
 	bulkSize int,
 	debug bool,
+	tracer *tracing.Tracer,
 ) *consumer.PredictConsumer {
 	predictConsumer := &consumer.PredictConsumer{
 		Source:      source,
@@ -69,6 +86,7 @@ func createPredictConsumer(
 		WorkerCount: workerCount,
 		BulkSize:    bulkSize,
 		Debug:       debug,
+		Tracer:      tracer,
 	}
 	return predictConsumer
 }
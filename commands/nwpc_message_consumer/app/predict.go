@@ -24,6 +24,8 @@ type predictCommand struct {
 	bulkSize    int
 
 	isDebug bool
+
+	tracingOptions
 }
 
 func (c *predictCommand) consumerPredict(cmd *cobra.Command, args []string) error {
@@ -39,6 +41,12 @@ func (c *predictCommand) consumerPredict(cmd *cobra.Command, args []string) erro
 		Queue:    c.rabbitmqQueueName,
 	}
 
+	tracer, err := c.createTracer("nwpc-message-consumer-predict")
+	if err != nil {
+		return err
+	}
+	defer tracer.Close()
+
 	target := consumer.ElasticSearchTarget{
 		Server: c.elasticServer,
 	}
@@ -49,9 +57,10 @@ func (c *predictCommand) consumerPredict(cmd *cobra.Command, args []string) erro
 		c.workerCount,
 		c.bulkSize,
 		c.isDebug,
+		tracer,
 	)
 
-	err := predictConsumer.ConsumeMessages()
+	err = predictConsumer.ConsumeMessages()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"component": "ecflow-client",
@@ -80,6 +89,7 @@ func newPredictCommand() *predictCommand {
 	predictCmd.Flags().IntVar(&ec.workerCount, "worker-count", 2, "worker count")
 	predictCmd.Flags().IntVar(&ec.bulkSize, "bulk-size", 20, "bulk size")
 	predictCmd.Flags().BoolVar(&ec.isDebug, "debug", true, "debug mode")
+	ec.addTracingFlags(predictCmd.Flags())
 
 	predictCmd.MarkFlagRequired("rabbitmq-server")
 	predictCmd.MarkFlagRequired("rabbitmq-queue-name")
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"github.com/nwpc-oper/nwpc-message-client/common"
 	"github.com/nwpc-oper/nwpc-message-client/common/consumer"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -24,9 +25,27 @@ type productionCommand struct {
 	bulkSize    int
 
 	isDebug bool
+
+	catalogue     string
+	catalogueMode string
+
+	timelineIndex string
+
+	tracingOptions
 }
 
 func (c *productionCommand) consumeProduction(cmd *cobra.Command, args []string) error {
+	tracer, err := c.createTracer("nwpc-message-consumer-production")
+	if err != nil {
+		return err
+	}
+	defer tracer.Close()
+
+	catalogue, err := c.loadCatalogue()
+	if err != nil {
+		return err
+	}
+
 	var currentConsumer consumer.Consumer = nil
 	currentSource := consumer.RabbitMQSource{
 		Server:   c.rabbitmqServer,
@@ -36,12 +55,14 @@ func (c *productionCommand) consumeProduction(cmd *cobra.Command, args []string)
 	}
 
 	if c.consumerType == string(printerConsumerType) {
-		currentConsumer = createPrinterConsumer(currentSource, c.workerCount, c.isDebug)
+		currentConsumer = createPrinterConsumer(currentSource, c.workerCount, c.isDebug, tracer)
 	} else if c.consumerType == string(elasticsearchConsumerType) {
 		target := consumer.ElasticSearchTarget{
 			Server: c.elasticServer,
 		}
-		currentConsumer = createElasticSearchConsumer(currentSource, target, c.workerCount, c.bulkSize, c.isDebug)
+		currentConsumer = createElasticSearchConsumer(
+			currentSource, target, c.workerCount, c.bulkSize, c.isDebug, tracer,
+			catalogue, c.catalogueMode, c.timelineIndex)
 	}
 
 	if currentConsumer == nil {
@@ -54,7 +75,7 @@ func (c *productionCommand) consumeProduction(cmd *cobra.Command, args []string)
 		"event":     "consumer",
 	}).Info("start to consume...")
 
-	err := currentConsumer.ConsumeMessages()
+	err = currentConsumer.ConsumeMessages()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"component": "production",
@@ -64,6 +85,17 @@ func (c *productionCommand) consumeProduction(cmd *cobra.Command, args []string)
 	return err
 }
 
+func (c *productionCommand) loadCatalogue() (*common.ProductCatalogue, error) {
+	err := common.CheckCatalogueMode(c.catalogueMode)
+	if err != nil {
+		return nil, err
+	}
+	if c.catalogue == "" {
+		return nil, nil
+	}
+	return common.LoadProductCatalogue(c.catalogue)
+}
+
 func newProductionCommand() *productionCommand {
 	pc := &productionCommand{}
 
@@ -90,6 +122,16 @@ func newProductionCommand() *productionCommand {
 
 	productionCmd.Flags().BoolVar(&pc.isDebug, "debug", true, "debug mode")
 
+	productionCmd.Flags().StringVar(&pc.catalogue,
+		"catalogue", "", "product catalogue file to check messages before indexing")
+	productionCmd.Flags().StringVar(&pc.catalogueMode,
+		"catalogue-mode", common.CatalogueModeWarn,
+		"catalogue check mode: strict drops invalid messages, warn only prints warnings")
+	productionCmd.Flags().StringVar(&pc.timelineIndex,
+		"timeline-index", "",
+		"index of timeline documents, one for each product, such as production-timeline. Disabled if empty.")
+	pc.addTracingFlags(productionCmd.Flags())
+
 	productionCmd.MarkFlagRequired("rabbitmq-server")
 	productionCmd.MarkFlagRequired("rabbitmq-queue-name")
 
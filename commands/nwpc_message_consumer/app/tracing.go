@@ -0,0 +1,23 @@
+package app
+
+import (
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
+	"github.com/spf13/pflag"
+)
+
+// tracingOptions are flags of trace exporter shared by consumer commands.
+type tracingOptions struct {
+	traceExporter string
+	traceFile     string
+}
+
+func (o *tracingOptions) addTracingFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.traceExporter,
+		"trace-exporter", tracing.ExporterNone, "exporter of trace spans in JSON Lines format, not OpenTelemetry protocol: none, stdout or file")
+	flags.StringVar(&o.traceFile,
+		"trace-file", "", "file to write trace spans in JSON Lines format, used by file exporter")
+}
+
+func (o *tracingOptions) createTracer(service string) (*tracing.Tracer, error) {
+	return tracing.CreateTracer(service, o.traceExporter, o.traceFile)
+}
@@ -0,0 +1,207 @@
+package common
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	AuditResultDelivered = "delivered"
+	AuditResultFailed    = "failed"
+	AuditResultDisabled  = "disabled"
+	AuditResultDuplicate = "duplicate"
+	AuditResultRejected  = "rejected"
+)
+
+// AuditEntry is one line in audit log.
+type AuditEntry struct {
+	ReceiveTime time.Time `json:"receive_time"`
+	Peer        string    `json:"peer"`
+	Client      string    `json:"client"`
+	Server      string    `json:"server"` // password is redacted
+	Exchange    string    `json:"exchange"`
+	RouteKey    string    `json:"route_key"`
+	Size        int       `json:"size"`
+	ID          string    `json:"id"`
+	Result      string    `json:"result"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// AuditLog writes audit entries into a file in JSON Lines format.
+//
+// Current file is rotated when its size is larger than MaxSize or it is older than MaxAge.
+// Rotated file is renamed with a timestamp suffix, and compressed with gzip if Compress is set.
+type AuditLog struct {
+	Path     string
+	MaxSize  int64
+	MaxAge   time.Duration
+	Compress bool
+
+	lock     sync.Mutex
+	file     *os.File
+	size     int64
+	openTime time.Time
+}
+
+func CreateAuditLog(path string, maxSize int64, maxAge time.Duration, compress bool) (*AuditLog, error) {
+	auditLog := &AuditLog{
+		Path:     path,
+		MaxSize:  maxSize,
+		MaxAge:   maxAge,
+		Compress: compress,
+	}
+	err := auditLog.open()
+	if err != nil {
+		return nil, err
+	}
+	return auditLog, nil
+}
+
+func (l *AuditLog) Write(entry AuditEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal audit entry has error: %v", err)
+	}
+	content = append(content, '\n')
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.needRotate(int64(len(content))) {
+		err = l.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(content)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write audit log has error: %v", err)
+	}
+	return nil
+}
+
+func (l *AuditLog) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.file.Close()
+}
+
+func (l *AuditLog) open() error {
+	file, err := os.OpenFile(l.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log has error: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat audit log has error: %v", err)
+	}
+	l.file = file
+	l.size = info.Size()
+	l.openTime = time.Now()
+	return nil
+}
+
+func (l *AuditLog) needRotate(writeSize int64) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.MaxSize > 0 && l.size+writeSize > l.MaxSize {
+		return true
+	}
+	if l.MaxAge > 0 && time.Since(l.openTime) > l.MaxAge {
+		return true
+	}
+	return false
+}
+
+// rename current file and open a new one. If rotation fails, the original path is opened again,
+// so that entries are still written, and rotation is tried in next write.
+func (l *AuditLog) rotate() error {
+	err := l.file.Close()
+	if err != nil {
+		logRotateError(fmt.Errorf("close audit log has error: %v", err))
+		return l.open()
+	}
+
+	rotatedPath := fmt.Sprintf("%s.%s", l.Path, time.Now().Format("20060102T150405.000000000"))
+	err = os.Rename(l.Path, rotatedPath)
+	if err != nil {
+		logRotateError(fmt.Errorf("rotate audit log has error: %v", err))
+		return l.open()
+	}
+
+	if l.Compress {
+		go compressAuditLog(rotatedPath)
+	}
+
+	return l.open()
+}
+
+func logRotateError(err error) {
+	log.WithFields(log.Fields{
+		"component": "broker",
+		"event":     "audit",
+	}).Errorf("%v", err)
+}
+
+// compress file into path.gz and remove it. File is kept if path.gz is not written completely.
+func compressAuditLog(path string) {
+	err := gzipFile(path)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "broker",
+			"event":     "audit",
+		}).Errorf("compress audit log %s has error: %v", path, err)
+		return
+	}
+	os.Remove(path)
+}
+
+// write path into path.gz, which is removed if any error occurs.
+func gzipFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	targetPath := path + ".gz"
+	target, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(target)
+	_, err = io.Copy(writer, source)
+	if err == nil {
+		err = writer.Close()
+	}
+	closeErr := target.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(targetPath)
+		return err
+	}
+	return nil
+}
+
+// remove password in server url.
+func redactServer(server string) string {
+	u, err := url.Parse(server)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,140 @@
+package common
+
+import (
+	"expvar"
+	"time"
+)
+
+const (
+	DefaultBatchMaxSize    = 500
+	DefaultBatchMinSize    = 1
+	DefaultBatchMaxLatency = 2 * time.Second
+)
+
+var (
+	batchLimitMetric       = new(expvar.Int)
+	batchSizeLastMetric    = new(expvar.Int)
+	batchLatencyLastMetric = new(expvar.Float)
+	batchLatencyMaxMetric  = new(expvar.Float)
+)
+
+func init() {
+	brokerMetrics.Set("batch_limit", batchLimitMetric)
+	brokerMetrics.Set("batch_size_last", batchSizeLastMetric)
+	brokerMetrics.Set("batch_latency_last_seconds", batchLatencyLastMetric)
+	brokerMetrics.Set("batch_latency_max_seconds", batchLatencyMaxMetric)
+}
+
+// MessageBatcher collects messages in batch mode and publishes them in batches.
+//
+// A batch is published when its size reaches the batch limit, or when its first message
+// has waited for MaxLatency. If Adaptive is enabled, batch limit grows up to MaxBatchSize
+// when batches are full and more messages are waiting, and shrinks down to MinBatchSize
+// when batches are flushed by timer.
+// Messages are published immediately when batch limit is MinBatchSize and no message is waiting,
+// that is when traffic is light.
+type MessageBatcher struct {
+	MaxBatchSize int
+	MinBatchSize int
+	MaxLatency   time.Duration
+	Adaptive     bool
+
+	Publish func(messages []RabbitMQMessage)
+
+	limit int
+}
+
+func (b *MessageBatcher) Run(messageChan chan RabbitMQMessage) {
+	if b.MaxBatchSize <= 0 {
+		b.MaxBatchSize = DefaultBatchMaxSize
+	}
+	if b.MinBatchSize <= 0 || b.MinBatchSize > b.MaxBatchSize {
+		b.MinBatchSize = DefaultBatchMinSize
+	}
+	if b.MaxLatency <= 0 {
+		b.MaxLatency = DefaultBatchMaxLatency
+	}
+
+	b.limit = b.MaxBatchSize
+	if b.Adaptive {
+		b.limit = b.MinBatchSize
+	}
+	batchLimitMetric.Set(int64(b.limit))
+
+	var received []RabbitMQMessage
+	var firstReceiveTime time.Time
+	timer := time.NewTimer(b.MaxLatency)
+	timer.Stop()
+
+	for {
+		select {
+		case message := <-messageChan:
+			if len(received) == 0 {
+				firstReceiveTime = time.Now()
+				timer.Reset(b.MaxLatency)
+			}
+			received = append(received, message)
+
+			if len(received) >= b.limit {
+				stopTimer(timer)
+				b.flush(received, firstReceiveTime)
+				if len(messageChan) > 0 {
+					b.adjustLimit(true)
+				}
+				received = nil
+			} else if b.Adaptive && b.limit == b.MinBatchSize && len(messageChan) == 0 {
+				stopTimer(timer)
+				b.flush(received, firstReceiveTime)
+				received = nil
+			}
+		case <-timer.C:
+			if len(received) > 0 {
+				b.flush(received, firstReceiveTime)
+				b.adjustLimit(false)
+				received = nil
+			}
+		}
+	}
+}
+
+func (b *MessageBatcher) flush(messages []RabbitMQMessage, firstReceiveTime time.Time) {
+	latency := time.Since(firstReceiveTime).Seconds()
+	brokerMetrics.Add("batches", 1)
+	brokerMetrics.Add("batch_messages", int64(len(messages)))
+	batchSizeLastMetric.Set(int64(len(messages)))
+	batchLatencyLastMetric.Set(latency)
+	if latency > batchLatencyMaxMetric.Value() {
+		batchLatencyMaxMetric.Set(latency)
+	}
+
+	go b.Publish(messages)
+}
+
+// double batch limit under load, halve it when batch is flushed by timer.
+func (b *MessageBatcher) adjustLimit(full bool) {
+	if !b.Adaptive {
+		return
+	}
+	if full {
+		b.limit *= 2
+		if b.limit > b.MaxBatchSize {
+			b.limit = b.MaxBatchSize
+		}
+	} else {
+		b.limit /= 2
+		if b.limit < b.MinBatchSize {
+			b.limit = b.MinBatchSize
+		}
+	}
+	batchLimitMetric.Set(int64(b.limit))
+}
+
+// stop timer and drain its channel.
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common/sender"
+	"google.golang.org/grpc/metadata"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ClientStat is message statistics of one client identity.
+type ClientStat struct {
+	Client    string  `json:"client"`
+	Messages  int64   `json:"messages"`
+	Bytes     int64   `json:"bytes"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// ClientStatistics aggregates counts, bytes and errors by client identity.
+type ClientStatistics struct {
+	lock  sync.Mutex
+	stats map[string]*ClientStat
+}
+
+func CreateClientStatistics() *ClientStatistics {
+	return &ClientStatistics{
+		stats: make(map[string]*ClientStat),
+	}
+}
+
+func (c *ClientStatistics) AddMessage(client string, size int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stat := c.getStat(client)
+	stat.Messages += 1
+	stat.Bytes += int64(size)
+}
+
+func (c *ClientStatistics) AddError(client string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getStat(client).Errors += 1
+}
+
+func (c *ClientStatistics) getStat(client string) *ClientStat {
+	stat, found := c.stats[client]
+	if !found {
+		stat = &ClientStat{Client: client}
+		c.stats[client] = stat
+	}
+	return stat
+}
+
+// Top returns n clients with most messages. All clients are returned if n <= 0.
+func (c *ClientStatistics) Top(n int) []ClientStat {
+	c.lock.Lock()
+	var stats []ClientStat
+	for _, stat := range c.stats {
+		s := *stat
+		if s.Messages > 0 {
+			s.ErrorRate = float64(s.Errors) / float64(s.Messages)
+		}
+		stats = append(stats, s)
+	}
+	c.lock.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Messages != stats[j].Messages {
+			return stats[i].Messages > stats[j].Messages
+		}
+		return stats[i].Client < stats[j].Client
+	})
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// Snapshot returns all client statistics, used by expvar.Func.
+func (c *ClientStatistics) Snapshot() interface{} {
+	return c.Top(0)
+}
+
+// get client identity from gRPC metadata, such as user@hostname ecflow=host:port suite=name.
+// Use peer host if no identity is sent by client.
+func getClientIdentity(ctx context.Context, peerAddress string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		values := md.Get(key)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+
+	var tokens []string
+	hostname := get(sender.MetadataClientHostname)
+	if hostname == "" {
+		hostname, _, _ = net.SplitHostPort(peerAddress)
+	}
+	if userName := get(sender.MetadataClientUser); userName != "" {
+		tokens = append(tokens, fmt.Sprintf("%s@%s", userName, hostname))
+	} else {
+		tokens = append(tokens, hostname)
+	}
+	if ecflowHost := get(sender.MetadataEcflowHost); ecflowHost != "" {
+		tokens = append(tokens, fmt.Sprintf("ecflow=%s:%s", ecflowHost, get(sender.MetadataEcflowPort)))
+	}
+	if suite := get(sender.MetadataEcflowSuite); suite != "" {
+		tokens = append(tokens, fmt.Sprintf("suite=%s", suite))
+	}
+	return strings.Join(tokens, " ")
+}
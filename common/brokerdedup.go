@@ -0,0 +1,100 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultDedupWindow is the default time window to remember message ids.
+const DefaultDedupWindow = 5 * time.Minute
+
+// DedupCache remembers ids of messages delivered in a time window, and ids of messages in delivery.
+//
+// An id is remembered as delivered only after delivery succeeds, so that a message can be sent
+// again after its delivery failed. A duplicate received while the first message is in delivery
+// waits for the result of the first one.
+type DedupCache struct {
+	Window time.Duration
+
+	lock        sync.Mutex
+	seen        map[string]time.Time
+	inFlight    map[string]*dedupAttempt
+	lastCleanup time.Time
+}
+
+// delivery of a message id, done is closed when delivery finishes.
+type dedupAttempt struct {
+	done chan struct{}
+}
+
+func CreateDedupCache(window time.Duration) *DedupCache {
+	return &DedupCache{
+		Window:   window,
+		seen:     make(map[string]time.Time),
+		inFlight: make(map[string]*dedupAttempt),
+	}
+}
+
+// Begin returns true if id has been delivered in the window. Otherwise id is marked in delivery,
+// and Finish should be called after delivery. If id is in delivery, Begin waits for its result,
+// and returns error if ctx is done before that. Empty id is never a duplicate.
+func (c *DedupCache) Begin(ctx context.Context, id string, now time.Time) (bool, error) {
+	if len(id) == 0 {
+		return false, nil
+	}
+
+	for {
+		c.lock.Lock()
+		if now.Sub(c.lastCleanup) > c.Window {
+			c.cleanup(now)
+		}
+
+		deliverTime, found := c.seen[id]
+		if found && now.Sub(deliverTime) <= c.Window {
+			c.lock.Unlock()
+			return true, nil
+		}
+		attempt, found := c.inFlight[id]
+		if !found {
+			c.inFlight[id] = &dedupAttempt{done: make(chan struct{})}
+			c.lock.Unlock()
+			return false, nil
+		}
+		c.lock.Unlock()
+
+		select {
+		case <-attempt.done:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// Finish ends delivery of id. Id is remembered only if message is delivered,
+// so that the message can be sent again after delivery failed.
+func (c *DedupCache) Finish(id string, now time.Time, delivered bool) {
+	if len(id) == 0 {
+		return
+	}
+	c.lock.Lock()
+	attempt, found := c.inFlight[id]
+	delete(c.inFlight, id)
+	if delivered {
+		c.seen[id] = now
+	}
+	c.lock.Unlock()
+
+	if found {
+		close(attempt.done)
+	}
+}
+
+func (c *DedupCache) cleanup(now time.Time) {
+	for id, deliverTime := range c.seen {
+		if now.Sub(deliverTime) > c.Window {
+			delete(c.seen, id)
+		}
+	}
+	c.lastCleanup = now
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/peer"
+	"time"
+)
+
+// AMQP headers added by broker. Message body is not changed.
+const (
+	HeaderBrokerReceiveTime = "x-broker-receive-time"
+	HeaderClientPeer        = "x-client-peer"
+	HeaderClientClockSkew   = "x-client-clock-skew"
+)
+
+// DefaultClockSkewThreshold is the default max difference between client time and broker time.
+const DefaultClockSkewThreshold = 30 * time.Second
+
+// get client address from grpc context. Return empty string if not found.
+func getPeerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	return p.Addr.String()
+}
+
+// create AMQP headers with broker receive time and client address,
+// and check clock skew between client and broker.
+func (s *MessageBrokerServer) createBrokerHeaders(
+	receiveTime time.Time,
+	peerAddress string,
+	message []byte,
+) map[string]interface{} {
+	headers := map[string]interface{}{
+		HeaderBrokerReceiveTime: receiveTime.Format(time.RFC3339Nano),
+		HeaderClientPeer:        peerAddress,
+	}
+
+	clientTime, ok := getClientTime(message)
+	if !ok {
+		return headers
+	}
+
+	skew := receiveTime.Sub(clientTime)
+	headers[HeaderClientClockSkew] = skew.String()
+
+	threshold := s.ClockSkewThreshold
+	if threshold <= 0 {
+		threshold = DefaultClockSkewThreshold
+	}
+	if skew > threshold || skew < -threshold {
+		brokerMetrics.Add(metricClockSkewMessages, 1)
+		log.WithFields(log.Fields{
+			"component": "broker",
+			"event":     "clock-skew",
+			"peer":      peerAddress,
+		}).Warnf("client time differs from broker time by %v: %s", skew, clientTime.Format(time.RFC3339Nano))
+	}
+
+	return headers
+}
+
+// get time field in EventMessage. Return false if message is not an EventMessage.
+func getClientTime(message []byte) (time.Time, bool) {
+	var event struct {
+		Time time.Time `json:"time"`
+	}
+	err := json.Unmarshal(message, &event)
+	if err != nil || event.Time.IsZero() {
+		return time.Time{}, false
+	}
+	return event.Time, true
+}
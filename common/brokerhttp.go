@@ -0,0 +1,194 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	pb "github.com/nwpc-oper/nwpc-message-client/common/messagebroker"
+	"github.com/nwpc-oper/nwpc-message-client/common/sender"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Max body size of HTTP request if max message size of broker is not set.
+const maxHTTPRequestSize = 10 * 1024 * 1024
+
+// Size of request body besides message, such as target and id.
+const httpRequestOverhead = 64 * 1024
+
+// Timeouts of HTTP server, so that slow clients can't hold connections.
+const (
+	httpReadHeaderTimeout = 10 * time.Second
+	httpReadTimeout       = 30 * time.Second
+	httpWriteTimeout      = 60 * time.Second
+	httpIdleTimeout       = 120 * time.Second
+)
+
+// HTTP request body for POST /v1/rabbitmq.
+// Message is set by message field as raw JSON, or by data field as base64 string.
+type httpMessageRequest struct {
+	Target struct {
+		Server   string `json:"server"`
+		Exchange string `json:"exchange"`
+		RouteKey string `json:"route_key"`
+	} `json:"target"`
+	ID      string          `json:"id"`
+	Message json.RawMessage `json:"message"`
+	Data    []byte          `json:"data"`
+}
+
+func (r *httpMessageRequest) getMessageData() ([]byte, error) {
+	if len(r.Message) > 0 && len(r.Data) > 0 {
+		return nil, fmt.Errorf("message and data can't be set at the same time")
+	}
+	if len(r.Message) > 0 {
+		return r.Message, nil
+	}
+	if len(r.Data) > 0 {
+		return r.Data, nil
+	}
+	return nil, fmt.Errorf("message or data is required")
+}
+
+type httpResponse struct {
+	ErrorNo      int32  `json:"error_no"`
+	ErrorMessage string `json:"error_message"`
+	Duplicate    bool   `json:"duplicate"`
+}
+
+// HTTPServer returns a http server of HTTPHandler with read and write timeouts.
+//
+// HTTP API has no authentication, same as gRPC service, so address should only be reachable from trusted hosts.
+func (s *MessageBrokerServer) HTTPServer(address string) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           s.HTTPHandler(),
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+		ReadTimeout:       httpReadTimeout,
+		WriteTimeout:      httpWriteTimeout,
+		IdleTimeout:       httpIdleTimeout,
+	}
+}
+
+// HTTPHandler returns a http handler which sends messages in the same way as gRPC service.
+//
+//	POST /v1/rabbitmq
+//	POST /v1/kafka, not implemented and returns 501
+func (s *MessageBrokerServer) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rabbitmq", s.handleHTTPRabbitMQMessage)
+	mux.HandleFunc("/v1/kafka", s.handleHTTPKafkaMessage)
+	return mux
+}
+
+func (s *MessageBrokerServer) handleHTTPRabbitMQMessage(w http.ResponseWriter, r *http.Request) {
+	request, data, ok := parseHTTPMessageRequest(w, r, s.httpRequestSizeLimit())
+	if !ok {
+		return
+	}
+
+	response, err := s.SendRabbitMQMessage(
+		createHTTPContext(r),
+		&pb.RabbitMQMessage{
+			Target: &pb.RabbitMQTarget{
+				Server:   request.Target.Server,
+				Exchange: request.Target.Exchange,
+				RouteKey: request.Target.RouteKey,
+			},
+			Message: &pb.Message{
+				Data: data,
+				Id:   request.ID,
+			},
+		},
+	)
+	writeHTTPResponse(w, response, err)
+}
+
+// Kafka delivery is not implemented by broker, so kafka messages are not accepted,
+// instead of returning success for messages which are not sent.
+func (s *MessageBrokerServer) handleHTTPKafkaMessage(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "kafka delivery is not implemented by broker", http.StatusNotImplemented)
+}
+
+// max body size of HTTP request. Message in body may be encoded in base64,
+// so the limit is twice of max message size if it is set.
+func (s *MessageBrokerServer) httpRequestSizeLimit() int64 {
+	if s.MaxMessageSize > 0 {
+		return int64(s.MaxMessageSize)*2 + httpRequestOverhead
+	}
+	return maxHTTPRequestSize
+}
+
+// parse request body. Write error response and return false if request is invalid.
+func parseHTTPMessageRequest(w http.ResponseWriter, r *http.Request, sizeLimit int64) (*httpMessageRequest, []byte, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, nil, false
+	}
+
+	var request httpMessageRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit))
+	err := decoder.Decode(&request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("parse request has error: %v", err), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	data, err := request.getMessageData()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return &request, data, true
+}
+
+// create context with client address and client identity in headers, same as gRPC.
+// Client identity uses the same names as gRPC metadata, such as Nwpc-Client-Hostname.
+func createHTTPContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for _, key := range []string{
+		sender.MetadataClientHostname,
+		sender.MetadataClientUser,
+		sender.MetadataEcflowHost,
+		sender.MetadataEcflowPort,
+		sender.MetadataEcflowSuite,
+		tracing.TraceparentHeader,
+	} {
+		if value := r.Header.Get(key); value != "" {
+			md.Set(key, value)
+		}
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+
+	addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err != nil {
+		return ctx
+	}
+	return peer.NewContext(ctx, &peer.Peer{Addr: addr})
+}
+
+func writeHTTPResponse(w http.ResponseWriter, response *pb.Response, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(httpResponse{
+		ErrorNo:      response.GetErrorNo(),
+		ErrorMessage: response.GetErrorMessage(),
+		Duplicate:    response.GetDuplicate(),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "broker",
+			"event":     "http",
+		}).Warnf("write response has error: %v", err)
+	}
+}
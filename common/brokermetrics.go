@@ -0,0 +1,14 @@
+package common
+
+import "expvar"
+
+// broker metrics, exported by expvar on /debug/vars.
+var brokerMetrics = expvar.NewMap("broker")
+
+const (
+	metricReceivedMessages  = "received_messages"
+	metricClockSkewMessages = "clock_skew_messages"
+	metricDuplicateMessages = "duplicate_messages"
+	metricRoutedMessages    = "routed_messages"
+	metricRejectedMessages  = "rejected_messages"
+)
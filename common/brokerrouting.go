@@ -0,0 +1,145 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RoutingRule sets exchange and route key for messages matching all patterns in Match.
+//
+// Keys in Match are field paths in EventMessage, such as type, data.system and data.stream.
+// Values are glob patterns, such as grapes_*. Exchange and RouteKey may use field paths in braces,
+// such as {data.system}.production.{data.type}. Empty exchange or route key keeps the one from client.
+// A rule is not matched if a field in its templates is not found in message.
+type RoutingRule struct {
+	Match    map[string]string `yaml:"match"`
+	Exchange string            `yaml:"exchange"`
+	RouteKey string            `yaml:"route_key"`
+}
+
+// Router computes exchange and route key from EventMessage using rules in order.
+// The first matched rule is used.
+//
+// Rules are loaded from a YAML file:
+//
+//	rules:
+//	  - match:
+//	      type: production
+//	      data.system: grapes_*
+//	    exchange: nwpc.operation.production
+//	    route_key: "{data.system}.production.{data.type}"
+type Router struct {
+	Rules []RoutingRule `yaml:"rules"`
+}
+
+var routingFieldPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+func LoadRouter(filePath string) (*Router, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read routing rules has error: %v", err)
+	}
+
+	var router Router
+	err = yaml.UnmarshalStrict(content, &router)
+	if err != nil {
+		return nil, fmt.Errorf("parse routing rules has error: %v", err)
+	}
+
+	for index, rule := range router.Rules {
+		for field, pattern := range rule.Match {
+			if _, err = path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %d: pattern of %s is invalid: %v", index, field, err)
+			}
+		}
+		for _, template := range []string{rule.Exchange, rule.RouteKey} {
+			remain := routingFieldPattern.ReplaceAllString(template, "")
+			if strings.ContainsAny(remain, "{}") {
+				return nil, fmt.Errorf("rule %d: template %s is invalid", index, template)
+			}
+		}
+	}
+	return &router, nil
+}
+
+// Route returns exchange and route key of the first matched rule.
+// ok is false if message is not an EventMessage or no rule is matched.
+func (r *Router) Route(message []byte) (exchange string, routeKey string, ok bool) {
+	var event map[string]interface{}
+	err := json.Unmarshal(message, &event)
+	if err != nil {
+		return "", "", false
+	}
+
+	for _, rule := range r.Rules {
+		if !rule.match(event) {
+			continue
+		}
+		exchange, found := renderRoutingTemplate(rule.Exchange, event)
+		if !found {
+			continue
+		}
+		routeKey, found = renderRoutingTemplate(rule.RouteKey, event)
+		if !found {
+			continue
+		}
+		return exchange, routeKey, true
+	}
+	return "", "", false
+}
+
+func (rule *RoutingRule) match(event map[string]interface{}) bool {
+	for field, pattern := range rule.Match {
+		value, found := getEventField(event, field)
+		if !found {
+			return false
+		}
+		matched, _ := path.Match(pattern, value)
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// replace fields in template with values in event. found is false if any field is not found.
+func renderRoutingTemplate(template string, event map[string]interface{}) (result string, found bool) {
+	found = true
+	result = routingFieldPattern.ReplaceAllStringFunc(template, func(s string) string {
+		value, ok := getEventField(event, s[1:len(s)-1])
+		if !ok {
+			found = false
+		}
+		return value
+	})
+	return result, found
+}
+
+// get field value as string by dot separated path, such as data.system.
+func getEventField(event map[string]interface{}, fieldPath string) (string, bool) {
+	var current interface{} = event
+	for _, name := range strings.Split(fieldPath, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		current, ok = object[name]
+		if !ok {
+			return "", false
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, true
+	case float64, bool:
+		return fmt.Sprintf("%v", value), true
+	default:
+		return "", false
+	}
+}
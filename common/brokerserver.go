@@ -5,6 +5,8 @@ import (
 	"fmt"
 	pb "github.com/nwpc-oper/nwpc-message-client/common/messagebroker"
 	"github.com/nwpc-oper/nwpc-message-client/common/sender"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
+	log "github.com/sirupsen/logrus"
 	"time"
 )
 
@@ -13,11 +15,28 @@ type MessageBrokerServer struct {
 	DisableDeliver bool
 	BrokerMode     string
 	MessageChan    chan RabbitMQMessage
+
+	ClockSkewThreshold time.Duration
+	DeadLetterStore    *DeadLetterStore
+	DedupCache         *DedupCache
+	AuditLog           *AuditLog
+	Router             *Router
+	ClientStatistics   *ClientStatistics
+	Tracer             *tracing.Tracer
+
+	MaxMessageSize  int
+	ValidateMessage bool
 }
 
 type RabbitMQMessage struct {
-	Target  sender.RabbitMQTarget
-	Message []byte
+	Target      sender.RabbitMQTarget
+	ID          string
+	Message     []byte
+	Headers     map[string]interface{}
+	ReceiveTime time.Time
+	Peer        string
+	Client      string
+	Span        *tracing.Span
 }
 
 func (s *MessageBrokerServer) SendRabbitMQMessage(
@@ -28,44 +47,172 @@ func (s *MessageBrokerServer) SendRabbitMQMessage(
 	//	"component": "broker",
 	//	"event":     "message",
 	//}).Infof("receiving message...%s\n", req.GetMessage().GetData())
-	if s.BrokerMode == "batch" {
-		rabbitmqTarget := sender.RabbitMQTarget{
+	brokerMetrics.Add(metricReceivedMessages, 1)
+	m := RabbitMQMessage{
+		Target: sender.RabbitMQTarget{
 			Server:       req.GetTarget().GetServer(),
 			Exchange:     req.GetTarget().GetExchange(),
 			RouteKey:     req.GetTarget().GetRouteKey(),
 			WriteTimeout: 2 * time.Second,
-		}
-		m := RabbitMQMessage{
-			Target:  rabbitmqTarget,
-			Message: req.GetMessage().GetData(),
-		}
+		},
+		ID:          req.GetMessage().GetId(),
+		Message:     req.GetMessage().GetData(),
+		ReceiveTime: time.Now(),
+		Peer:        getPeerAddress(ctx),
+	}
+	m.Client = getClientIdentity(ctx, m.Peer)
+	if s.ClientStatistics != nil {
+		s.ClientStatistics.AddMessage(m.Client, len(m.Message))
+	}
+	s.startReceiveSpan(ctx, &m)
+
+	err := s.validateMessage(m.Message)
+	if err != nil {
+		brokerMetrics.Add(metricRejectedMessages, 1)
+		log.WithFields(log.Fields{
+			"component": "broker",
+			"event":     "validate",
+			"peer":      m.Peer,
+		}).Warnf("reject message: %v", err)
+		s.ReportDeliveryResult([]RabbitMQMessage{m}, AuditResultRejected, err)
+		response := &pb.Response{}
+		response.ErrorNo = ErrorNoInvalidMessage
+		response.ErrorMessage = fmt.Sprintf("message is rejected: %v", err)
+		return response, nil
+	}
+
+	duplicate, err := s.beginDelivery(ctx, m.ID, m.ReceiveTime)
+	if err != nil {
+		endReceiveSpans([]RabbitMQMessage{m}, AuditResultFailed, err)
+		return nil, fmt.Errorf("wait for delivery of message %s has error: %v", m.ID, err)
+	}
+	if duplicate {
+		brokerMetrics.Add(metricDuplicateMessages, 1)
+		log.WithFields(log.Fields{
+			"component": "broker",
+			"event":     "dedup",
+			"peer":      m.Peer,
+		}).Infof("drop duplicate message: %s", m.ID)
+		s.ReportDeliveryResult([]RabbitMQMessage{m}, AuditResultDuplicate, nil)
+		response := &pb.Response{}
+		response.ErrorNo = 0
+		response.Duplicate = true
+		return response, nil
+	}
+
+	m.Headers = s.createBrokerHeaders(m.ReceiveTime, m.Peer, m.Message)
+	s.routeMessage(&m)
+	injectTraceHeaders(&m)
+
+	if s.BrokerMode == "batch" {
 		s.MessageChan <- m
 
 		response := &pb.Response{}
 		response.ErrorNo = 0
 		return response, nil
 	} else {
-		server := req.GetTarget().GetServer()
-		exchange := req.GetTarget().GetExchange()
-		routeKey := req.GetTarget().GetRouteKey()
-
-		rabbitSender := sender.CreateRabbitMQSender(server, exchange, routeKey, 2*time.Second)
+		rabbitSender := &sender.RabbitMQSender{
+			Target:    m.Target,
+			MessageID: m.ID,
+			Headers:   m.Headers,
+		}
 
 		response := &pb.Response{}
 		response.ErrorNo = 0
 
-		if !s.DisableDeliver {
-			err := rabbitSender.SendMessage(req.GetMessage().GetData())
+		if s.DisableDeliver {
+			s.ReportDeliveryResult([]RabbitMQMessage{m}, AuditResultDisabled, nil)
+			return response, nil
+		}
 
-			if err != nil {
-				response.ErrorMessage = fmt.Sprintf("send messge has error: %s", err)
-			}
+		err = rabbitSender.SendMessage(m.Message)
+		if err != nil {
+			response.ErrorMessage = fmt.Sprintf("send messge has error: %s", err)
+			s.ReportDeliveryResult([]RabbitMQMessage{m}, AuditResultFailed, err)
+		} else {
+			s.ReportDeliveryResult([]RabbitMQMessage{m}, AuditResultDelivered, nil)
 		}
 
 		return response, nil
 	}
 }
 
+// set exchange and route key by routing rules if any rule is matched.
+func (s *MessageBrokerServer) routeMessage(m *RabbitMQMessage) {
+	if s.Router == nil {
+		return
+	}
+	exchange, routeKey, ok := s.Router.Route(m.Message)
+	if !ok {
+		return
+	}
+	brokerMetrics.Add(metricRoutedMessages, 1)
+	if len(exchange) > 0 {
+		m.Target.Exchange = exchange
+	}
+	if len(routeKey) > 0 {
+		m.Target.RouteKey = routeKey
+	}
+}
+
+// check duplicate message and mark id in delivery. Delivery is finished in ReportDeliveryResult.
+func (s *MessageBrokerServer) beginDelivery(ctx context.Context, id string, receiveTime time.Time) (bool, error) {
+	if s.DedupCache == nil {
+		return false, nil
+	}
+	return s.DedupCache.Begin(ctx, id, receiveTime)
+}
+
+// ReportDeliveryResult writes delivery result of messages into audit log,
+// saves failed messages into dead letter store, ends trace spans of messages,
+// and remembers ids of delivered messages for dedup.
+func (s *MessageBrokerServer) ReportDeliveryResult(messages []RabbitMQMessage, result string, err error) {
+	endReceiveSpans(messages, result, err)
+	if s.DedupCache != nil &&
+		(result == AuditResultDelivered || result == AuditResultDisabled || result == AuditResultFailed) {
+		now := time.Now()
+		for _, message := range messages {
+			s.DedupCache.Finish(message.ID, now, result != AuditResultFailed)
+		}
+	}
+	if err != nil && result == AuditResultFailed {
+		s.DeadLetterStore.SaveMessages(messages, err)
+	}
+	if s.ClientStatistics != nil && (result == AuditResultFailed || result == AuditResultRejected) {
+		for _, message := range messages {
+			s.ClientStatistics.AddError(message.Client)
+		}
+	}
+	if s.AuditLog == nil {
+		return
+	}
+
+	errorMessage := ""
+	if err != nil {
+		errorMessage = err.Error()
+	}
+	for _, message := range messages {
+		writeErr := s.AuditLog.Write(AuditEntry{
+			ReceiveTime: message.ReceiveTime,
+			Peer:        message.Peer,
+			Client:      message.Client,
+			Server:      redactServer(message.Target.Server),
+			Exchange:    message.Target.Exchange,
+			RouteKey:    message.Target.RouteKey,
+			Size:        len(message.Message),
+			ID:          message.ID,
+			Result:      result,
+			Error:       errorMessage,
+		})
+		if writeErr != nil {
+			log.WithFields(log.Fields{
+				"component": "broker",
+				"event":     "audit",
+			}).Errorf("write audit log failed: %v", writeErr)
+		}
+	}
+}
+
 func (s *MessageBrokerServer) SendKafkaMessage(
 	ctx context.Context,
 	req *pb.KafkaMessage,
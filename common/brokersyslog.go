@@ -0,0 +1,230 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	pb "github.com/nwpc-oper/nwpc-message-client/common/messagebroker"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/peer"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LogMessageType     = "log"
+	DefaultLogExchange = "nwpc.operation.log"
+	defaultSyslogType  = "syslog"
+	maxSyslogFrameSize = 64 * 1024
+)
+
+// SyslogServer receives syslog messages over UDP and TCP, and publishes them as log messages
+// to RabbitMQ using the broker.
+//
+// System is app name of syslog message. Log type and start time can be set by
+// structured data params type and start_time (YYYYMMDDHH).
+type SyslogServer struct {
+	Broker         *MessageBrokerServer
+	App            string
+	RabbitMQServer string
+	Exchange       string
+}
+
+func (s *SyslogServer) ListenUDP(address string) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen udp: %v", err)
+	}
+	defer conn.Close()
+
+	buffer := make([]byte, maxSyslogFrameSize)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return fmt.Errorf("read udp has error: %v", err)
+		}
+		s.handleLine(string(buffer[:n]), addr)
+	}
+}
+
+func (s *SyslogServer) ListenTCP(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen tcp: %v", err)
+	}
+	defer listener.Close()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return fmt.Errorf("accept tcp has error: %v", err)
+		}
+		go s.handleTCPConnection(conn)
+	}
+}
+
+// read frames using octet counting or non-transparent framing (RFC 6587).
+func (s *SyslogServer) handleTCPConnection(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := readSyslogFrame(reader)
+		if len(line) > 0 {
+			s.handleLine(line, conn.RemoteAddr())
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.WithFields(log.Fields{
+					"component": "syslog",
+					"event":     "receive",
+				}).Warnf("read tcp has error: %v", err)
+			}
+			return
+		}
+	}
+}
+
+func readSyslogFrame(reader *bufio.Reader) (string, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	if first[0] < '0' || first[0] > '9' {
+		return reader.ReadString('\n')
+	}
+
+	lengthString, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(lengthString))
+	if err != nil || length > maxSyslogFrameSize {
+		return "", fmt.Errorf("frame length is invalid: %s", lengthString)
+	}
+	frame := make([]byte, length)
+	_, err = io.ReadFull(reader, frame)
+	if err != nil {
+		return "", err
+	}
+	return string(frame), nil
+}
+
+func (s *SyslogServer) handleLine(line string, addr net.Addr) {
+	if len(strings.TrimSpace(line)) == 0 {
+		return
+	}
+
+	message, err := ParseSyslogMessage(line)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "syslog",
+			"event":     "parse",
+			"peer":      addr.String(),
+		}).Warnf("parse syslog message has error: %v", err)
+		return
+	}
+	if len(message.AppName) == 0 {
+		log.WithFields(log.Fields{
+			"component": "syslog",
+			"event":     "parse",
+			"peer":      addr.String(),
+		}).Warnf("app name is not found, message is dropped: %s", line)
+		return
+	}
+
+	data := CreateSyslogLogData(message)
+	messageBytes, err := json.Marshal(EventMessage{
+		App:  s.App,
+		Type: LogMessageType,
+		Time: time.Now(),
+		Data: data,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "syslog",
+			"event":     "create",
+		}).Errorf("create message has error: %v", err)
+		return
+	}
+
+	exchange := s.Exchange
+	if len(exchange) == 0 {
+		exchange = DefaultLogExchange
+	}
+
+	response, err := s.Broker.SendRabbitMQMessage(
+		peer.NewContext(context.Background(), &peer.Peer{Addr: addr}),
+		&pb.RabbitMQMessage{
+			Target: &pb.RabbitMQTarget{
+				Server:   s.RabbitMQServer,
+				Exchange: exchange,
+				RouteKey: fmt.Sprintf("%s.log.%s", data.System, data.Type),
+			},
+			Message: &pb.Message{
+				Data: messageBytes,
+			},
+		},
+	)
+	if err == nil && len(response.GetErrorMessage()) > 0 {
+		err = fmt.Errorf("%s", response.GetErrorMessage())
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "syslog",
+			"event":     "send",
+		}).Errorf("send message has error: %v", err)
+	}
+}
+
+// IANA registered SD-IDs, which are not put into content.
+var syslogReservedStructuredDataIDs = map[string]bool{
+	"timeQuality": true,
+	"origin":      true,
+	"meta":        true,
+}
+
+// CreateSyslogLogData converts syslog message into LogMessageData.
+// All structured data params except IANA registered ones and message text are put into content.
+func CreateSyslogLogData(message *SyslogMessage) LogMessageData {
+	content := make(map[string]string)
+	for id, params := range message.StructuredData {
+		if syslogReservedStructuredDataIDs[id] {
+			continue
+		}
+		for name, value := range params {
+			content[name] = value
+		}
+	}
+	content["message"] = message.Message
+	if len(message.Hostname) > 0 {
+		content["hostname"] = message.Hostname
+	}
+
+	logType := defaultSyslogType
+	if value, ok := content["type"]; ok && len(value) > 0 {
+		logType = value
+	}
+
+	var startTime time.Time
+	if value, ok := content["start_time"]; ok {
+		startTime, _ = time.Parse("2006010215", value)
+	}
+
+	logTime := message.Time
+	if logTime.IsZero() {
+		logTime = time.Now()
+	}
+
+	return LogMessageData{
+		System:    message.AppName,
+		StartTime: startTime,
+		Time:      logTime,
+		Level:     message.SeverityLevel(),
+		Type:      logType,
+		Content:   content,
+	}
+}
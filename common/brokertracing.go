@@ -0,0 +1,51 @@
+package common
+
+import (
+	"context"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
+	"google.golang.org/grpc/metadata"
+)
+
+// start a span for received message as a child of traceparent in gRPC metadata.
+// A new trace is started if client doesn't send traceparent.
+func (s *MessageBrokerServer) startReceiveSpan(ctx context.Context, m *RabbitMQMessage) {
+	var parent tracing.SpanContext
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(tracing.TraceparentHeader); len(values) > 0 {
+		parent, _ = tracing.ParseTraceparent(values[0])
+	}
+
+	span := s.Tracer.StartSpan("broker.receive", tracing.SpanKindServer, parent)
+	span.SetAttribute("messaging.message_id", m.ID)
+	span.SetAttribute("messaging.message_payload_size_bytes", len(m.Message))
+	span.SetAttribute("net.peer", m.Peer)
+	span.SetAttribute("client", m.Client)
+	m.Span = span
+}
+
+// pass span context of broker to consumers in AMQP headers.
+func injectTraceHeaders(m *RabbitMQMessage) {
+	if m.Span == nil {
+		return
+	}
+	m.Headers[tracing.TraceparentHeader] = m.Span.Context().Traceparent()
+}
+
+// end spans of messages with delivery result.
+func endReceiveSpans(messages []RabbitMQMessage, result string, err error) {
+	for _, message := range messages {
+		span := message.Span
+		if span == nil {
+			continue
+		}
+		span.SetAttribute("messaging.destination", message.Target.Exchange)
+		span.SetAttribute("messaging.rabbitmq.routing_key", message.Target.RouteKey)
+		span.SetAttribute("result", result)
+		if result == AuditResultFailed || result == AuditResultRejected {
+			span.SetError(err)
+		} else {
+			span.SetError(nil)
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common/sender"
+	"strings"
+)
+
+// ErrorNoInvalidMessage is the error number in response when message is rejected.
+// Clients don't try to send rejected messages again.
+const ErrorNoInvalidMessage = sender.ErrorNoInvalidMessage
+
+// check message size, and check message is an EventMessage if ValidateMessage is set.
+func (s *MessageBrokerServer) validateMessage(message []byte) error {
+	if s.MaxMessageSize > 0 && len(message) > s.MaxMessageSize {
+		return fmt.Errorf("message size %d is larger than limit %d", len(message), s.MaxMessageSize)
+	}
+	if !s.ValidateMessage {
+		return nil
+	}
+	return ValidateEventMessage(message)
+}
+
+// ValidateEventMessage checks message is an EventMessage with non-empty app, type and time.
+func ValidateEventMessage(message []byte) error {
+	var event EventMessage
+	err := json.Unmarshal(message, &event)
+	if err != nil {
+		return fmt.Errorf("message is not a valid EventMessage: %v", err)
+	}
+
+	var missingFields []string
+	if len(event.App) == 0 {
+		missingFields = append(missingFields, "app")
+	}
+	if len(event.Type) == 0 {
+		missingFields = append(missingFields, "type")
+	}
+	if event.Time.IsZero() {
+		missingFields = append(missingFields, "time")
+	}
+	if len(missingFields) > 0 {
+		return fmt.Errorf("message is not a valid EventMessage: field(s) %s empty",
+			strings.Join(missingFields, ", "))
+	}
+	return nil
+}
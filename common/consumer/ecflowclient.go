@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	"github.com/olivere/elastic/v7"
 	log "github.com/sirupsen/logrus"
 	"github.com/streadway/amqp"
@@ -17,6 +18,7 @@ type EcflowClientConsumer struct {
 	WorkerCount int
 	BulkSize    int
 	Debug       bool
+	Tracer      *tracing.Tracer
 }
 
 func (s *EcflowClientConsumer) ConsumeMessages() error {
@@ -87,6 +89,7 @@ func consumeMessageToElastic(consumer *EcflowClientConsumer, messages <-chan amq
 	for {
 		select {
 		case d := <-messages:
+			span := startDeliverySpan(consumer.Tracer, "consumer.ecflow-client", d)
 			// parse message to generate message index
 			//log.WithFields(log.Fields{
 			//	"component": "elastic",
@@ -104,13 +107,14 @@ func consumeMessageToElastic(consumer *EcflowClientConsumer, messages <-chan amq
 					"component": "consumer",
 					"event":     "message",
 				}).Errorf("error message body: %v", d.Body)
+				endSpan(span, err)
 				continue
 			}
 
 			indexName := getIndexForEcflowClientMessage(event)
 
 			received = append(received, messageWithIndex{
-				indexName, event,
+				indexName, event, span,
 			})
 			//log.WithFields(log.Fields{
 			//	"component": "elastic",
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	"github.com/olivere/elastic/v7"
 	log "github.com/sirupsen/logrus"
 )
@@ -15,6 +16,7 @@ type ElasticSearchTarget struct {
 type messageWithIndex struct {
 	Index   string
 	Message common.EventMessage
+	Span    *tracing.Span
 }
 
 func pushMessages(client *elastic.Client, messages []messageWithIndex, ctx context.Context) error {
@@ -33,5 +35,13 @@ func pushMessages(client *elastic.Client, messages []messageWithIndex, ctx conte
 		}).Errorf("%v", err)
 		return fmt.Errorf("push message failed: %v", err)
 	}
+
+	// spans of failed messages are ended in next successful push.
+	for _, indexMessage := range messages {
+		if indexMessage.Span != nil {
+			indexMessage.Span.SetAttribute("elasticsearch.index", indexMessage.Index)
+		}
+		endSpan(indexMessage.Span, nil)
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package consumer
 
 import (
 	"context"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	"github.com/segmentio/kafka-go"
 	log "github.com/sirupsen/logrus"
 )
@@ -34,6 +35,7 @@ type KafkaPrinterConsumer struct {
 	WorkerCount  int
 	ConsumerName string
 	Debug        bool
+	Tracer       *tracing.Tracer
 }
 
 func (s *KafkaPrinterConsumer) ConsumeMessages() error {
@@ -53,7 +55,9 @@ func (s *KafkaPrinterConsumer) ConsumeMessages() error {
 		if err != nil {
 			break
 		}
+		span := startKafkaSpan(s.Tracer, "consumer.kafka-printer", m)
 		log.Printf("%s", string(m.Value))
+		endSpan(span, nil)
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package consumer
 
 import (
 	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -10,6 +11,7 @@ type PrinterConsumer struct {
 	WorkerCount  int
 	ConsumerName string
 	Debug        bool
+	Tracer       *tracing.Tracer
 }
 
 func (s *PrinterConsumer) ConsumeMessages() error {
@@ -51,7 +53,9 @@ func (s *PrinterConsumer) ConsumeMessages() error {
 	for i := 0; i < s.WorkerCount; i++ {
 		go func() {
 			for message := range messages {
+				span := startDeliverySpan(s.Tracer, "consumer.printer", message)
 				log.Infof("%s", message.Body)
+				endSpan(span, nil)
 			}
 		}()
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	"github.com/olivere/elastic/v7"
 	log "github.com/sirupsen/logrus"
 	"github.com/streadway/amqp"
@@ -17,6 +18,14 @@ type ProductionConsumer struct {
 	WorkerCount int
 	BulkSize    int
 	Debug       bool
+	Tracer      *tracing.Tracer
+
+	// messages are checked by Catalogue before indexing if it is set.
+	Catalogue     *common.ProductCatalogue
+	CatalogueMode string
+
+	// events of each product are added into timeline documents in this index if it is set.
+	TimelineIndex string
 }
 
 func (s *ProductionConsumer) ConsumeMessages() error {
@@ -87,6 +96,7 @@ func consumeProductionMessageToElastic(consumer *ProductionConsumer, messages <-
 	for {
 		select {
 		case d := <-messages:
+			span := startDeliverySpan(consumer.Tracer, "consumer.production", d)
 			// parse message to generate message index
 			//log.WithFields(log.Fields{
 			//	"component": "elastic",
@@ -104,13 +114,23 @@ func consumeProductionMessageToElastic(consumer *ProductionConsumer, messages <-
 					"component": "consumer",
 					"event":     "message",
 				}).Errorf("error message body: %v", d.Body)
+				endSpan(span, err)
+				continue
+			}
+
+			// messages of old clients have no forecast hour and valid time.
+			common.FillForecastFields(event.Data)
+
+			err = consumer.checkCatalogue(event)
+			if err != nil {
+				endSpan(span, err)
 				continue
 			}
 
 			indexName := getIndexForProductionMessage(event)
 
 			received = append(received, messageWithIndex{
-				indexName, event,
+				indexName, event, span,
 			})
 			//log.WithFields(log.Fields{
 			//	"component": "elastic",
@@ -134,6 +154,7 @@ func consumeProductionMessageToElastic(consumer *ProductionConsumer, messages <-
 						"component": "elastic",
 						"event":     "push",
 					}).Infof("bulk size push...done, %d", len(received))
+					consumer.pushTimelines(client, received, ctx)
 					received = nil
 				}
 			}
@@ -155,6 +176,7 @@ func consumeProductionMessageToElastic(consumer *ProductionConsumer, messages <-
 						"component": "elastic",
 						"event":     "push",
 					}).Infof("time limit push...done, %d", len(received))
+					consumer.pushTimelines(client, received, ctx)
 					received = nil
 				}
 			}
@@ -168,6 +190,43 @@ func consumeProductionMessageToElastic(consumer *ProductionConsumer, messages <-
 	}
 }
 
+// add events into timelines of products. Errors are only logged, because messages have been indexed.
+func (s *ProductionConsumer) pushTimelines(client *elastic.Client, messages []messageWithIndex, ctx context.Context) {
+	if s.TimelineIndex == "" {
+		return
+	}
+	err := pushTimelines(client, s.TimelineIndex, messages, ctx)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "elastic",
+			"event":     "timeline",
+		}).Warnf("%v", err)
+	}
+}
+
+// check message with catalogue. Error is returned only in strict mode, and message should be dropped.
+func (s *ProductionConsumer) checkCatalogue(event common.EventMessage) error {
+	if s.Catalogue == nil {
+		return nil
+	}
+	err := s.Catalogue.ValidateMessage(event)
+	if err == nil {
+		return nil
+	}
+	if s.CatalogueMode == common.CatalogueModeStrict {
+		log.WithFields(log.Fields{
+			"component": "consumer",
+			"event":     "catalogue",
+		}).Errorf("drop message: %v", err)
+		return err
+	}
+	log.WithFields(log.Fields{
+		"component": "consumer",
+		"event":     "catalogue",
+	}).Warnf("%v", err)
+	return nil
+}
+
 func getIndexForProductionMessage(event common.EventMessage) string {
 	messageTime := event.Time
 	indexName := messageTime.Format("2006-01")
@@ -0,0 +1,147 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common"
+	"github.com/olivere/elastic/v7"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// fields of production data which identify a product. Fields not in data are ignored.
+// forecast_time is normalized into forecast hours in product key, so that 24h, 024h and 1d are the same product.
+var productKeyFields = []string{
+	"system",
+	"stream",
+	"type",
+	"name",
+	"start_time",
+	"reference_date",
+	"forecast_time",
+	"number",
+	"domain_id",
+}
+
+// fields of production data which are put into timeline entries.
+var timelineEntryFields = []string{
+	"event",
+	"status",
+	"check_result",
+	"check_message",
+	"source_format",
+	"target_format",
+	"archive_path",
+	"destination_host",
+	"destination_path",
+	"files",
+}
+
+const timelineTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// add entry into events of timeline, and update last event if entry is the latest one.
+const timelineScript = `
+if (ctx._source.events == null) {
+	ctx._source.events = [];
+}
+ctx._source.events.add(params.entry);
+if (ctx._source.last_time == null || params.entry.time.compareTo(ctx._source.last_time) >= 0) {
+	ctx._source.last_event = params.entry.event;
+	ctx._source.last_time = params.entry.time;
+}
+`
+
+// pushTimelines appends events of production messages into timeline documents, one document for each product.
+// Document id is the product key, such as grapes_gfs_gmf/oper/grib2/orig/2021-04-22T00:00:00Z/24h.
+func pushTimelines(client *elastic.Client, index string, messages []messageWithIndex, ctx context.Context) error {
+	bulkRequest := client.Bulk()
+	for _, indexMessage := range messages {
+		data, ok := indexMessage.Message.Data.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		product := make(map[string]interface{})
+		var keys []string
+		for _, field := range productKeyFields {
+			value, found := data[field]
+			if !found {
+				continue
+			}
+			product[field] = value
+			keys = append(keys, productKeyValue(field, value, data))
+		}
+
+		// fixed width time, so that times can be compared as strings in script.
+		entry := map[string]interface{}{
+			"time": indexMessage.Message.Time.UTC().Format(timelineTimeLayout),
+		}
+		for _, field := range timelineEntryFields {
+			if value, found := data[field]; found {
+				entry[field] = value
+			}
+		}
+
+		upsert := make(map[string]interface{})
+		for key, value := range product {
+			upsert[key] = value
+		}
+		upsert["events"] = []interface{}{entry}
+		upsert["last_event"] = entry["event"]
+		upsert["last_time"] = entry["time"]
+
+		request := elastic.NewBulkUpdateRequest().
+			Index(index).
+			Id(strings.Join(keys, "/")).
+			RetryOnConflict(3).
+			Script(elastic.NewScript(timelineScript).Params(map[string]interface{}{
+				"entry": entry,
+			})).
+			Upsert(upsert)
+		bulkRequest.Add(request)
+	}
+	if bulkRequest.NumberOfActions() == 0 {
+		return nil
+	}
+
+	response, err := bulkRequest.Do(ctx)
+	if err != nil {
+		return fmt.Errorf("push timelines failed: %v", err)
+	}
+	if response.Errors {
+		failed := response.Failed()
+		reason := ""
+		if len(failed) > 0 && failed[0].Error != nil {
+			reason = failed[0].Error.Reason
+		}
+		return fmt.Errorf("push timelines has %d failed items, first error: %s", len(failed), reason)
+	}
+	return nil
+}
+
+// value of field in product key. Forecast time uses forecast_hour if it is in data, or is parsed from
+// forecast_time string. Numbers are formatted as integers if possible, because numbers are float64 in data.
+func productKeyValue(field string, value interface{}, data map[string]interface{}) string {
+	if field == "forecast_time" {
+		if forecastHour, ok := data["forecast_hour"].(float64); ok {
+			return formatNumber(forecastHour) + "h"
+		}
+		if forecastTime, ok := value.(string); ok {
+			if duration, err := common.ParseForecastTime(forecastTime); err == nil {
+				return formatNumber(duration.Hours()) + "h"
+			}
+		}
+	}
+	if number, ok := value.(float64); ok {
+		return formatNumber(number)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func formatNumber(number float64) string {
+	if number == math.Trunc(number) && math.Abs(number) < 1e15 {
+		return strconv.FormatInt(int64(number), 10)
+	}
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
@@ -0,0 +1,42 @@
+package consumer
+
+import (
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
+	"github.com/segmentio/kafka-go"
+	"github.com/streadway/amqp"
+)
+
+// start a consumer span as a child of traceparent in AMQP headers, which is set by broker or sender.
+func startDeliverySpan(tracer *tracing.Tracer, name string, d amqp.Delivery) *tracing.Span {
+	span := tracer.StartSpan(name, tracing.SpanKindConsumer, tracing.ExtractHeaders(d.Headers))
+	span.SetAttribute("messaging.message_id", d.MessageId)
+	span.SetAttribute("messaging.destination", d.Exchange)
+	span.SetAttribute("messaging.rabbitmq.routing_key", d.RoutingKey)
+	span.SetAttribute("messaging.message_payload_size_bytes", len(d.Body))
+	return span
+}
+
+// start a consumer span as a child of traceparent in Kafka headers.
+func startKafkaSpan(tracer *tracing.Tracer, name string, m kafka.Message) *tracing.Span {
+	var parent tracing.SpanContext
+	for _, header := range m.Headers {
+		if header.Key == tracing.TraceparentHeader {
+			parent, _ = tracing.ParseTraceparent(string(header.Value))
+		}
+	}
+	span := tracer.StartSpan(name, tracing.SpanKindConsumer, parent)
+	span.SetAttribute("messaging.destination", m.Topic)
+	span.SetAttribute("messaging.kafka.partition", m.Partition)
+	span.SetAttribute("messaging.kafka.offset", m.Offset)
+	span.SetAttribute("messaging.message_payload_size_bytes", len(m.Value))
+	return span
+}
+
+// end span with err as status.
+func endSpan(span *tracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	span.SetError(err)
+	span.End()
+}
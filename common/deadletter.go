@@ -0,0 +1,139 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common/sender"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const deadLetterFileSuffix = ".json"
+
+// DeadLetter is a message which broker failed to deliver.
+type DeadLetter struct {
+	Time     time.Time              `json:"time"`   // failure time
+	Reason   string                 `json:"reason"` // failure reason
+	Server   string                 `json:"server"`
+	Exchange string                 `json:"exchange"`
+	RouteKey string                 `json:"route_key"`
+	ID       string                 `json:"id,omitempty"`
+	Headers  map[string]interface{} `json:"headers,omitempty"`
+	Message  []byte                 `json:"message"`
+
+	Path string `json:"-"` // file path in dead letter directory
+}
+
+func (d *DeadLetter) ToRabbitMQMessage() RabbitMQMessage {
+	return RabbitMQMessage{
+		Target: sender.RabbitMQTarget{
+			Server:       d.Server,
+			Exchange:     d.Exchange,
+			RouteKey:     d.RouteKey,
+			WriteTimeout: 2 * time.Second,
+		},
+		ID:      d.ID,
+		Message: d.Message,
+		Headers: d.Headers,
+	}
+}
+
+// DeadLetterStore saves undeliverable messages into a directory, one file for each message.
+type DeadLetterStore struct {
+	Dir   string
+	count uint64
+}
+
+func CreateDeadLetterStore(dir string) (*DeadLetterStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("create dead letter directory has error: %v", err)
+	}
+	return &DeadLetterStore{
+		Dir: dir,
+	}, nil
+}
+
+func (store *DeadLetterStore) Save(message RabbitMQMessage, reason error) error {
+	now := time.Now()
+	deadLetter := DeadLetter{
+		Time:     now,
+		Reason:   reason.Error(),
+		Server:   message.Target.Server,
+		Exchange: message.Target.Exchange,
+		RouteKey: message.Target.RouteKey,
+		ID:       message.ID,
+		Headers:  message.Headers,
+		Message:  message.Message,
+	}
+
+	content, err := json.Marshal(deadLetter)
+	if err != nil {
+		return fmt.Errorf("marshal dead letter has error: %v", err)
+	}
+
+	fileName := fmt.Sprintf("%s-%06d%s",
+		now.UTC().Format("20060102T150405.000000000"),
+		atomic.AddUint64(&store.count, 1)%1000000,
+		deadLetterFileSuffix,
+	)
+	err = ioutil.WriteFile(filepath.Join(store.Dir, fileName), content, 0600)
+	if err != nil {
+		return fmt.Errorf("write dead letter has error: %v", err)
+	}
+	return nil
+}
+
+// LoadDeadLetters reads all dead letters in directory, sorted by file name.
+func LoadDeadLetters(dir string) ([]DeadLetter, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dead letter directory has error: %v", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	var deadLetters []DeadLetter
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), deadLetterFileSuffix) {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read dead letter %s has error: %v", path, err)
+		}
+		var deadLetter DeadLetter
+		err = json.Unmarshal(content, &deadLetter)
+		if err != nil {
+			return nil, fmt.Errorf("parse dead letter %s has error: %v", path, err)
+		}
+		deadLetter.Path = path
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, nil
+}
+
+// SaveMessages saves messages with the same failure reason. Errors are only logged.
+// Nothing is saved if store is nil, which means dead letter is disabled.
+func (store *DeadLetterStore) SaveMessages(messages []RabbitMQMessage, reason error) {
+	if store == nil {
+		return
+	}
+	for _, message := range messages {
+		err := store.Save(message, reason)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"component": "broker",
+				"event":     "dead-letter",
+			}).Errorf("save dead letter failed: %v", err)
+		}
+	}
+}
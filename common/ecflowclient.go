@@ -1,12 +1,29 @@
 package common
 
 import (
-	"github.com/jessevdk/go-flags"
+	"fmt"
 	"os"
 	"strings"
 )
 
+// CreateEcflowClientMessage creates message data from command options string of ecflow_client,
+// such as --label=info "running step 2".
+//
+// Command options which are not valid in shell syntax, such as unquoted "$*" with an apostrophe,
+// are split by whitespace like the legacy parser.
 func CreateEcflowClientMessage(commandOptions string) (*EcflowClientData, error) {
+	args, err := SplitCommandOptions(commandOptions)
+	if err != nil {
+		args = strings.Fields(commandOptions)
+	}
+	return CreateEcflowClientMessageFromArgs(args)
+}
+
+// CreateEcflowClientMessageFromArgs creates message data from args of ecflow_client.
+//
+// If args don't match grammar of the command, such as --init without process id, only Command and Arguments
+// are set like the legacy parser, and the error is put into ParseError, so that the message is still sent.
+func CreateEcflowClientMessageFromArgs(args []string) (*EcflowClientData, error) {
 	data := &EcflowClientData{
 		EcflowHost: os.Getenv("ECF_HOST"),
 		EcflowPort: os.Getenv("ECF_PORT"),
@@ -14,15 +31,21 @@ func CreateEcflowClientMessage(commandOptions string) (*EcflowClientData, error)
 		NodeRID:    os.Getenv("ECF_RID"),
 		TryNo:      os.Getenv("ECF_TRYNO"),
 		EcfDate:    os.Getenv("ECF_DATE"),
+		Job:        GetJobContext(),
+	}
+	err := data.ParseCommandArgs(args)
+	if err != nil {
+		command, host, port := parseLegacyEcflowClientArgs(args)
+		data.EcflowCommand = command
+		data.setServer(host, port)
+		data.ParseError = err.Error()
 	}
-	data.ParseCommandOptions(commandOptions)
 
 	return data, nil
 }
 
 type EcflowClientData struct {
-	Command    string              `json:"command"`
-	Arguments  []string            `json:"args"`
+	EcflowCommand
 	Envs       []map[string]string `json:"envs"`
 	EcflowHost string              `json:"ecf_host"`
 	EcflowPort string              `json:"ecf_port"`
@@ -30,37 +53,41 @@ type EcflowClientData struct {
 	NodeRID    string              `json:"ecf_rid"`
 	TryNo      string              `json:"ecf_tryno"`
 	EcfDate    string              `json:"ecf_date"`
-}
+	Job        *JobContext         `json:"job,omitempty"`
 
-func (d *EcflowClientData) ParseCommandOptions(commandOptions string) {
-	var opts struct {
-		Host string `long:"host" description:"ecflow host"`
-		Port string `long:"port" description:"ecflow port"`
-	}
+	// error of parsing command, only Command and Arguments are set if it is not empty.
+	ParseError string `json:"parse_error,omitempty"`
 
-	parser := flags.NewParser(&opts, flags.Default)
-	parser.UnknownOptionHandler = func(option string, arg flags.SplitArgument, args []string) (i []string, e error) {
-		value, _ := arg.Value()
-		d.Command = option
-		if len(value) > 0 {
-			d.Arguments = append(d.Arguments, value)
-		}
-		return args, nil
-	}
-
-	tokens := strings.Fields(commandOptions)
+	// set by ecflow-client exec command, which runs ecflow_client.
+	ExitCode   *int    `json:"exit_code,omitempty"`
+	Duration   float64 `json:"duration,omitempty"` // seconds
+	StderrTail string  `json:"stderr_tail,omitempty"`
+}
 
-	remainArgs, err := parser.ParseArgs(tokens)
+func (d *EcflowClientData) ParseCommandOptions(commandOptions string) error {
+	args, err := SplitCommandOptions(commandOptions)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return d.ParseCommandArgs(args)
+}
 
-	d.Arguments = append(d.Arguments, remainArgs...)
+// ParseCommandArgs parses args of ecflow_client. Host and port in args overwrite values from environment variables.
+func (d *EcflowClientData) ParseCommandArgs(args []string) error {
+	command, host, port, err := ParseEcflowClientArgs(args)
+	if err != nil {
+		return fmt.Errorf("parse ecflow_client command has error: %v", err)
+	}
+	d.EcflowCommand = command
+	d.setServer(host, port)
+	return nil
+}
 
-	if len(opts.Host) != 0 {
-		d.EcflowHost = opts.Host
+func (d *EcflowClientData) setServer(host string, port string) {
+	if len(host) != 0 {
+		d.EcflowHost = host
 	}
-	if len(opts.Port) != 0 {
-		d.EcflowPort = opts.Port
+	if len(port) != 0 {
+		d.EcflowPort = port
 	}
 }
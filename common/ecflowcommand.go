@@ -0,0 +1,354 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EcflowCommand is a parsed ecflow_client command, such as
+//
+//	--label=info "running step 2"
+//	--alter add variable ECF_RID 1 /grapes_gfs_gmf/00/post
+//
+// Command and Arguments are kept for all commands. Other fields are filled by known commands.
+type EcflowCommand struct {
+	Command   string   `json:"command"`
+	Arguments []string `json:"args"`
+
+	// paths of nodes in user commands, such as alter, force and requeue.
+	NodePaths []string `json:"node_paths,omitempty"`
+
+	ProcessID      string `json:"process_id,omitempty"`
+	AbortReason    string `json:"abort_reason,omitempty"`
+	EventName      string `json:"event_name,omitempty"`
+	EventValue     string `json:"event_value,omitempty"`
+	MeterName      string `json:"meter_name,omitempty"`
+	MeterValue     *int   `json:"meter_value,omitempty"`
+	LabelName      string `json:"label_name,omitempty"`
+	LabelText      string `json:"label_text,omitempty"`
+	WaitExpression string `json:"wait_expression,omitempty"`
+	QueueName      string `json:"queue_name,omitempty"`
+
+	AlterChange    string `json:"alter_change,omitempty"`
+	AlterAttribute string `json:"alter_attribute,omitempty"`
+	AlterName      string `json:"alter_name,omitempty"`
+	AlterValue     string `json:"alter_value,omitempty"`
+
+	ForceState string `json:"force_state,omitempty"`
+	Recursive  bool   `json:"recursive,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+}
+
+// global options of ecflow_client, which are not commands.
+var ecflowGlobalOptions = map[string]bool{
+	"host":     true,
+	"port":     true,
+	"rid":      true,
+	"user":     true,
+	"password": true,
+	"ssl":      true,
+	"debug":    true,
+}
+
+var ecflowAlterChanges = map[string]bool{
+	"add":        true,
+	"change":     true,
+	"delete":     true,
+	"set_flag":   true,
+	"clear_flag": true,
+	"sort":       true,
+}
+
+// ecflowOption is an option in command line with its values, such as --meter=progress 10.
+type ecflowOption struct {
+	name   string
+	values []string
+}
+
+// ParseEcflowClientArgs parses arguments of ecflow_client.
+// Host and port are returned from --host and --port options.
+func ParseEcflowClientArgs(args []string) (command EcflowCommand, host string, port string, err error) {
+	options, err := splitEcflowOptions(args)
+	if err != nil {
+		return command, "", "", err
+	}
+
+	var commandOption *ecflowOption
+	for index := range options {
+		option := &options[index]
+		if ecflowGlobalOptions[option.name] {
+			switch option.name {
+			case "host":
+				host, err = getSingleValue(option)
+			case "port":
+				port, err = getSingleValue(option)
+			}
+			if err != nil {
+				return command, "", "", err
+			}
+			continue
+		}
+		if commandOption != nil {
+			return command, "", "", fmt.Errorf("only one command is allowed: --%s, --%s",
+				commandOption.name, option.name)
+		}
+		commandOption = option
+	}
+	if commandOption == nil {
+		return command, "", "", fmt.Errorf("command is not found in args: %s", strings.Join(args, " "))
+	}
+
+	command.Command = commandOption.name
+	command.Arguments = commandOption.values
+	err = command.parseValues()
+	return command, host, port, err
+}
+
+// parseLegacyEcflowClientArgs gets command and arguments without checking grammar of the command,
+// like parser of old versions. Command is the last option which is not a global option,
+// and arguments are inline values of options and all other args, such as
+//
+//	--label=info it's done  =>  label [info it's done]
+func parseLegacyEcflowClientArgs(args []string) (command EcflowCommand, host string, port string) {
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+		if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+			command.Arguments = append(command.Arguments, arg)
+			continue
+		}
+		tokens := strings.SplitN(arg[2:], "=", 2)
+		name := tokens[0]
+		if name == "host" || name == "port" {
+			value := ""
+			if len(tokens) == 2 {
+				value = tokens[1]
+			} else if index+1 < len(args) {
+				index++
+				value = args[index]
+			}
+			if name == "host" {
+				host = value
+			} else {
+				port = value
+			}
+			continue
+		}
+		if ecflowGlobalOptions[name] {
+			continue
+		}
+		command.Command = name
+		if len(tokens) == 2 && tokens[1] != "" {
+			command.Arguments = append(command.Arguments, tokens[1])
+		}
+	}
+	return command, host, port
+}
+
+// SplitCommandOptions splits command options string into args like a shell,
+// with single quotes, double quotes and backslash escapes.
+func SplitCommandOptions(commandOptions string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(commandOptions)
+	for index := 0; index < len(runes); index++ {
+		c := runes[index]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else if c == '\\' && index+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[index+1]) {
+				index++
+				current.WriteRune(runes[index])
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\':
+			if index+1 == len(runes) {
+				return nil, fmt.Errorf("trailing backslash in command options")
+			}
+			index++
+			current.WriteRune(runes[index])
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command options: %s", commandOptions)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// group args by options. Values of an option are its inline value after = and following args
+// until next option.
+func splitEcflowOptions(args []string) ([]ecflowOption, error) {
+	var options []ecflowOption
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			tokens := strings.SplitN(arg[2:], "=", 2)
+			option := ecflowOption{name: tokens[0]}
+			if len(tokens) == 2 {
+				option.values = append(option.values, tokens[1])
+			}
+			options = append(options, option)
+			continue
+		}
+		if len(options) == 0 {
+			return nil, fmt.Errorf("argument is not after an option: %s", arg)
+		}
+		last := &options[len(options)-1]
+		last.values = append(last.values, arg)
+	}
+	return options, nil
+}
+
+func getSingleValue(option *ecflowOption) (string, error) {
+	if len(option.values) != 1 {
+		return "", fmt.Errorf("--%s needs one value: %v", option.name, option.values)
+	}
+	return option.values[0], nil
+}
+
+// fill structured fields from Arguments according to Command.
+func (c *EcflowCommand) parseValues() error {
+	values, paths := splitNodePaths(c.Arguments)
+
+	switch c.Command {
+	case "init":
+		if len(c.Arguments) != 1 {
+			return fmt.Errorf("--init needs process id: %v", c.Arguments)
+		}
+		c.ProcessID = c.Arguments[0]
+	case "complete":
+		if len(c.Arguments) != 0 {
+			return fmt.Errorf("--complete has no argument: %v", c.Arguments)
+		}
+	case "abort":
+		c.AbortReason = strings.Join(c.Arguments, " ")
+	case "event":
+		if len(c.Arguments) == 0 || len(c.Arguments) > 2 {
+			return fmt.Errorf("--event needs event name and optional set or clear: %v", c.Arguments)
+		}
+		c.EventName = c.Arguments[0]
+		if len(c.Arguments) == 2 {
+			if c.Arguments[1] != "set" && c.Arguments[1] != "clear" {
+				return fmt.Errorf("--event value should be set or clear: %s", c.Arguments[1])
+			}
+			c.EventValue = c.Arguments[1]
+		}
+	case "meter":
+		if len(c.Arguments) != 2 {
+			return fmt.Errorf("--meter needs meter name and value: %v", c.Arguments)
+		}
+		value, err := strconv.Atoi(c.Arguments[1])
+		if err != nil {
+			return fmt.Errorf("--meter value is not an integer: %s", c.Arguments[1])
+		}
+		c.MeterName = c.Arguments[0]
+		c.MeterValue = &value
+	case "label":
+		if len(c.Arguments) == 0 {
+			return fmt.Errorf("--label needs label name and text")
+		}
+		c.LabelName = c.Arguments[0]
+		c.LabelText = strings.Join(c.Arguments[1:], " ")
+	case "wait":
+		if len(c.Arguments) == 0 {
+			return fmt.Errorf("--wait needs an expression")
+		}
+		c.WaitExpression = strings.Join(c.Arguments, " ")
+	case "queue":
+		if len(c.Arguments) < 2 {
+			return fmt.Errorf("--queue needs queue name and action: %v", c.Arguments)
+		}
+		c.QueueName = c.Arguments[0]
+		c.Mode = c.Arguments[1]
+	case "alter":
+		return c.parseAlter(values, paths)
+	case "force":
+		if len(values) == 0 || len(paths) == 0 {
+			return fmt.Errorf("--force needs state and node paths: %v", c.Arguments)
+		}
+		c.ForceState = values[0]
+		for _, value := range values[1:] {
+			if value != "recursive" && value != "full" {
+				return fmt.Errorf("--force option is not supported: %s", value)
+			}
+			c.Recursive = true
+		}
+		c.NodePaths = paths
+	case "requeue", "run", "delete":
+		if len(paths) == 0 {
+			return fmt.Errorf("--%s needs node paths: %v", c.Command, c.Arguments)
+		}
+		if len(values) > 1 {
+			return fmt.Errorf("--%s has too many options: %v", c.Command, values)
+		}
+		if len(values) == 1 {
+			c.Mode = values[0]
+		}
+		c.NodePaths = paths
+	default:
+		c.NodePaths = paths
+	}
+	return nil
+}
+
+// --alter change_type attr_type [name] [value] paths...
+func (c *EcflowCommand) parseAlter(values []string, paths []string) error {
+	// value of variable may be a path, such as --alter change variable ECF_HOME /home/ecflow /suite
+	if len(values) >= 2 && values[1] == "variable" && (values[0] == "add" || values[0] == "change") {
+		for len(values) < 4 && len(paths) > 1 {
+			values = append(values, paths[0])
+			paths = paths[1:]
+		}
+	}
+	if len(values) < 2 || len(paths) == 0 {
+		return fmt.Errorf("--alter needs change type, attribute type and node paths: %v", c.Arguments)
+	}
+	if !ecflowAlterChanges[values[0]] {
+		return fmt.Errorf("--alter change type is not supported: %s", values[0])
+	}
+	c.AlterChange = values[0]
+	c.AlterAttribute = values[1]
+	if len(values) > 2 {
+		c.AlterName = values[2]
+	}
+	if len(values) > 3 {
+		c.AlterValue = strings.Join(values[3:], " ")
+	}
+	c.NodePaths = paths
+	return nil
+}
+
+// split args into values and node paths. Node paths start with / and are at the end of args.
+func splitNodePaths(args []string) ([]string, []string) {
+	index := len(args)
+	for index > 0 && strings.HasPrefix(args[index-1], "/") {
+		index--
+	}
+	return args[:index], args[index:]
+}
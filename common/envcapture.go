@@ -0,0 +1,90 @@
+package common
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces values of secret-looking environment variables.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactPatterns are glob patterns of secret-looking variable names, matched case-insensitively.
+var DefaultRedactPatterns = []string{
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*PASS",
+	"*SECRET*",
+	"*TOKEN*",
+	"*CREDENTIAL*",
+	"*_KEY",
+	"*APIKEY*",
+	"*AUTH*",
+}
+
+// EnvCapture selects environment variables sent in ecflow-client messages.
+//
+// Names are variable names or glob patterns, such as SLURM_JOB_ID and PBS_*.
+// Values of variables matching RedactPatterns case-insensitively are replaced with RedactedValue,
+// and passwords in URL values are removed.
+type EnvCapture struct {
+	Names          []string
+	RedactPatterns []string
+}
+
+// Capture returns selected variables in environ, which is in the format of os.Environ.
+// Each item is a map with name and value, sorted by name.
+func (c *EnvCapture) Capture(environ []string) []map[string]string {
+	if len(c.Names) == 0 {
+		return nil
+	}
+
+	var envs []map[string]string
+	for _, item := range environ {
+		tokens := strings.SplitN(item, "=", 2)
+		if len(tokens) != 2 || !matchAnyPattern(c.Names, tokens[0]) {
+			continue
+		}
+		envs = append(envs, map[string]string{
+			"name":  tokens[0],
+			"value": c.redact(tokens[0], tokens[1]),
+		})
+	}
+	sort.Slice(envs, func(i, j int) bool {
+		return envs[i]["name"] < envs[j]["name"]
+	})
+	return envs
+}
+
+// CaptureEnvironment sets Envs with variables in current environment.
+func (d *EcflowClientData) CaptureEnvironment(capture *EnvCapture) {
+	d.Envs = capture.Capture(os.Environ())
+}
+
+func (c *EnvCapture) redact(name string, value string) string {
+	upperName := strings.ToUpper(name)
+	for _, pattern := range c.RedactPatterns {
+		if matched, _ := path.Match(strings.ToUpper(pattern), upperName); matched {
+			return RedactedValue
+		}
+	}
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			if _, hasPassword := u.User.Password(); hasPassword {
+				return u.Redacted()
+			}
+		}
+	}
+	return value
+}
+
+func matchAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -1,5 +1,10 @@
 package common
 
+import (
+	"fmt"
+	"strings"
+)
+
 type EventStatus int
 
 const (
@@ -38,3 +43,13 @@ func ToEventStatus(status string) EventStatus {
 	}
 	return Unknown
 }
+
+// ParseEventStatus is like ToEventStatus, but returns error for unknown status names.
+func ParseEventStatus(status string) (EventStatus, error) {
+	for index, s := range statusList {
+		if s == status {
+			return EventStatus(index), nil
+		}
+	}
+	return Unknown, fmt.Errorf("status is not valid: %s, valid values: %s", status, strings.Join(statusList[:], ", "))
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var forecastTimeUnits = map[byte]time.Duration{
+	'd': 24 * time.Hour,
+	'h': time.Hour,
+	'm': time.Minute,
+}
+
+// ParseForecastTime parses forecast time string into duration. Forecast time is a sequence of
+// numbers with units d (day), h (hour) and m (minute), such as 000h, 3h, 10d, 90m and 1h30m.
+// Number without unit is in hours, such as 024.
+func ParseForecastTime(forecastTime string) (time.Duration, error) {
+	if forecastTime == "" {
+		return 0, fmt.Errorf("forecast time is empty")
+	}
+
+	var duration time.Duration
+	index := 0
+	for index < len(forecastTime) {
+		start := index
+		for index < len(forecastTime) && forecastTime[index] >= '0' && forecastTime[index] <= '9' {
+			index++
+		}
+		if start == index {
+			return 0, fmt.Errorf("forecast time %s is invalid: number is expected at %d", forecastTime, start)
+		}
+		value, err := strconv.Atoi(forecastTime[start:index])
+		if err != nil {
+			return 0, fmt.Errorf("forecast time %s is invalid: %v", forecastTime, err)
+		}
+
+		unit := time.Hour
+		if index < len(forecastTime) {
+			var found bool
+			unit, found = forecastTimeUnits[forecastTime[index]]
+			if !found {
+				return 0, fmt.Errorf("forecast time %s is invalid: unit should be d, h or m", forecastTime)
+			}
+			index++
+		} else if start != 0 {
+			return 0, fmt.Errorf("forecast time %s is invalid: unit is missing at the end", forecastTime)
+		}
+		duration += time.Duration(value) * unit
+	}
+	return duration, nil
+}
+
+// ComputeForecastFields returns forecast hour and valid time for forecast time string.
+// Minutes are fractions of forecast hour, such as 1.5 for 90m.
+func ComputeForecastFields(startTime time.Time, forecastTime string) (*float64, *time.Time, error) {
+	duration, err := ParseForecastTime(forecastTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	forecastHour := duration.Hours()
+	validTime := startTime.Add(duration)
+	return &forecastHour, &validTime, nil
+}
+
+// FillForecastFields adds forecast_hour and valid_time into production data unmarshalled from messages
+// of old clients, which only have forecast_time string. Data which can't be parsed is not changed.
+func FillForecastFields(data interface{}) {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if _, found := fields["forecast_hour"]; found {
+		return
+	}
+	forecastTime, ok := fields["forecast_time"].(string)
+	if !ok {
+		return
+	}
+	startTimeString, ok := fields["start_time"].(string)
+	if !ok {
+		return
+	}
+	startTime, err := time.Parse(time.RFC3339, startTimeString)
+	if err != nil {
+		return
+	}
+	forecastHour, validTime, err := ComputeForecastFields(startTime, forecastTime)
+	if err != nil {
+		return
+	}
+	fields["forecast_hour"] = *forecastHour
+	fields["valid_time"] = *validTime
+}
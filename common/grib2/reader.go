@@ -0,0 +1,267 @@
+// Package grib2 reads time and ensemble information of GRIB2 files without external libraries.
+//
+// Only section 0 (indicator), section 1 (identification) and section 4 (product definition)
+// are decoded. Other sections are skipped.
+package grib2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Indicators of unit of time range, code table 4.4.
+const (
+	TimeUnitMinute  = 0
+	TimeUnitHour    = 1
+	TimeUnitDay     = 2
+	TimeUnitMonth   = 3
+	TimeUnitYear    = 4
+	TimeUnit3Hours  = 10
+	TimeUnit6Hours  = 11
+	TimeUnit12Hours = 12
+	TimeUnitSecond  = 13
+	TimeUnitMissing = 255
+)
+
+const (
+	indicatorSize    = 16
+	sectionHeadSize  = 5
+	endSectionMarker = "7777"
+)
+
+var timeUnitDurations = map[int]time.Duration{
+	TimeUnitMinute:  time.Minute,
+	TimeUnitHour:    time.Hour,
+	TimeUnitDay:     24 * time.Hour,
+	TimeUnit3Hours:  3 * time.Hour,
+	TimeUnit6Hours:  6 * time.Hour,
+	TimeUnit12Hours: 12 * time.Hour,
+	TimeUnitSecond:  time.Second,
+}
+
+// Field is time and ensemble information of one field (one section 4) in a GRIB2 message.
+type Field struct {
+	Discipline         int
+	ReferenceTime      time.Time // section 1, UTC
+	TemplateNumber     int       // product definition template number
+	ForecastTime       int       // forecast time in TimeUnit
+	TimeUnit           int       // code table 4.4
+	EndTime            *time.Time
+	PerturbationNumber *int // ensemble member number, only in ensemble templates
+}
+
+// Forecast returns forecast time as duration. For statistically processed fields,
+// such as accumulated precipitation, forecast time is end of overall time interval.
+func (f Field) Forecast() (time.Duration, error) {
+	if f.EndTime != nil {
+		return f.EndTime.Sub(f.ReferenceTime), nil
+	}
+	unit, found := timeUnitDurations[f.TimeUnit]
+	if !found {
+		return 0, fmt.Errorf("unit of time range is not supported: %d", f.TimeUnit)
+	}
+	return time.Duration(f.ForecastTime) * unit, nil
+}
+
+// ReadFile reads fields of all messages in a GRIB2 file.
+func ReadFile(path string) ([]Field, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open grib2 file has error: %v", err)
+	}
+	defer file.Close()
+
+	fields, err := ReadFields(file)
+	if err != nil {
+		return nil, fmt.Errorf("read grib2 file %s has error: %v", path, err)
+	}
+	return fields, nil
+}
+
+// ReadFields reads fields of all messages from r. Data sections are skipped by seeking.
+// Fields with product definition templates which are not supported are ignored.
+func ReadFields(r io.ReadSeeker) ([]Field, error) {
+	var fields []Field
+	for messageIndex := 1; ; messageIndex++ {
+		indicator := make([]byte, indicatorSize)
+		_, err := io.ReadFull(r, indicator)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read section 0 of message %d has error: %v", messageIndex, err)
+		}
+		if string(indicator[0:4]) != "GRIB" {
+			return nil, fmt.Errorf("message %d does not start with GRIB", messageIndex)
+		}
+		if indicator[7] != 2 {
+			return nil, fmt.Errorf("message %d is GRIB edition %d, only edition 2 is supported",
+				messageIndex, indicator[7])
+		}
+
+		messageFields, err := readSections(r, int(indicator[6]))
+		if err != nil {
+			return nil, fmt.Errorf("read message %d has error: %v", messageIndex, err)
+		}
+		fields = append(fields, messageFields...)
+	}
+	return fields, nil
+}
+
+// read sections 1 to 8 of one message.
+func readSections(r io.ReadSeeker, discipline int) ([]Field, error) {
+	var fields []Field
+	var referenceTime *time.Time
+	for {
+		head := make([]byte, 4)
+		_, err := io.ReadFull(r, head)
+		if err != nil {
+			return nil, fmt.Errorf("read section has error: %v", err)
+		}
+		if string(head) == endSectionMarker {
+			return fields, nil
+		}
+
+		length := int64(binary.BigEndian.Uint32(head))
+		number := make([]byte, 1)
+		_, err = io.ReadFull(r, number)
+		if err != nil {
+			return nil, fmt.Errorf("read section number has error: %v", err)
+		}
+		if length < sectionHeadSize {
+			return nil, fmt.Errorf("length of section %d is invalid: %d", number[0], length)
+		}
+
+		switch number[0] {
+		case 1, 4:
+			body := make([]byte, length-sectionHeadSize)
+			_, err = io.ReadFull(r, body)
+			if err != nil {
+				return nil, fmt.Errorf("read section %d has error: %v", number[0], err)
+			}
+			if number[0] == 1 {
+				t, err := parseIdentification(body)
+				if err != nil {
+					return nil, err
+				}
+				referenceTime = &t
+				continue
+			}
+			if referenceTime == nil {
+				return nil, fmt.Errorf("section 4 is before section 1")
+			}
+			field, supported, err := parseProductDefinition(body, *referenceTime)
+			if err != nil {
+				return nil, err
+			}
+			if supported {
+				field.Discipline = discipline
+				fields = append(fields, field)
+			}
+		default:
+			_, err = r.Seek(length-sectionHeadSize, io.SeekCurrent)
+			if err != nil {
+				return nil, fmt.Errorf("skip section %d has error: %v", number[0], err)
+			}
+		}
+	}
+}
+
+// octets in comments are octets in section, body starts from octet 6.
+func octet(body []byte, index int) int {
+	return int(body[index-6])
+}
+
+func octets16(body []byte, index int) int {
+	return int(binary.BigEndian.Uint16(body[index-6:]))
+}
+
+// signed integers in GRIB2 use the first bit as sign.
+func signedOctets32(body []byte, index int) int {
+	value := binary.BigEndian.Uint32(body[index-6:])
+	if value&0x80000000 != 0 {
+		return -int(value & 0x7fffffff)
+	}
+	return int(value)
+}
+
+// octets 13-19 of section 1 are reference time.
+func parseIdentification(body []byte) (time.Time, error) {
+	if len(body)+sectionHeadSize < 21 {
+		return time.Time{}, fmt.Errorf("section 1 is too short: %d", len(body)+sectionHeadSize)
+	}
+	return parseTime(body, 13), nil
+}
+
+// time in 7 octets: year(2), month, day, hour, minute, second.
+func parseTime(body []byte, index int) time.Time {
+	return time.Date(
+		octets16(body, index),
+		time.Month(octet(body, index+2)),
+		octet(body, index+3),
+		octet(body, index+4),
+		octet(body, index+5),
+		octet(body, index+6),
+		0,
+		time.UTC,
+	)
+}
+
+// octet of end of overall time interval in statistically processed templates.
+var endTimeOctets = map[int]int{
+	8:  35,
+	11: 38,
+	12: 37,
+}
+
+// octet of perturbation number in ensemble templates.
+var perturbationOctets = map[int]int{
+	1:  36,
+	11: 36,
+}
+
+// parse template 4.0, 4.1, 4.2, 4.8, 4.11 and 4.12, which have forecast time in octets 18-22.
+func parseProductDefinition(body []byte, referenceTime time.Time) (Field, bool, error) {
+	sectionLength := len(body) + sectionHeadSize
+	if sectionLength < 9 {
+		return Field{}, false, fmt.Errorf("section 4 is too short: %d", sectionLength)
+	}
+	templateNumber := octets16(body, 8)
+	switch templateNumber {
+	case 0, 1, 2, 8, 11, 12:
+	default:
+		return Field{}, false, nil
+	}
+	if sectionLength < 34 {
+		return Field{}, false, fmt.Errorf("section 4 of template 4.%d is too short: %d",
+			templateNumber, sectionLength)
+	}
+
+	field := Field{
+		ReferenceTime:  referenceTime,
+		TemplateNumber: templateNumber,
+		TimeUnit:       octet(body, 18),
+		ForecastTime:   signedOctets32(body, 19),
+	}
+
+	if index, found := perturbationOctets[templateNumber]; found {
+		if sectionLength < index {
+			return Field{}, false, fmt.Errorf("section 4 of template 4.%d is too short: %d",
+				templateNumber, sectionLength)
+		}
+		number := octet(body, index)
+		field.PerturbationNumber = &number
+	}
+	if index, found := endTimeOctets[templateNumber]; found {
+		if sectionLength < index+6 {
+			return Field{}, false, fmt.Errorf("section 4 of template 4.%d is too short: %d",
+				templateNumber, sectionLength)
+		}
+		endTime := parseTime(body, index)
+		field.EndTime = &endTime
+	}
+	return field, true, nil
+}
@@ -0,0 +1,251 @@
+package grib2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// section with length and section number before body.
+func buildSection(number byte, body []byte) []byte {
+	section := make([]byte, 5, 5+len(body))
+	binary.BigEndian.PutUint32(section, uint32(5+len(body)))
+	section[4] = number
+	return append(section, body...)
+}
+
+// body of section 1 with reference time.
+func buildIdentification(referenceTime time.Time) []byte {
+	body := []byte{0, 38, 0, 0, 2, 1, 1} // octets 6-12: centre, sub-centre, table versions, significance
+	body = append(body, buildTime(referenceTime)...)
+	return append(body, 0, 1) // octets 20-21: production status, type of data
+}
+
+// time in 7 octets: year(2), month, day, hour, minute, second.
+func buildTime(t time.Time) []byte {
+	return []byte{
+		byte(t.Year() >> 8), byte(t.Year()),
+		byte(t.Month()), byte(t.Day()), byte(t.Hour()), byte(t.Minute()), byte(t.Second()),
+	}
+}
+
+// body of section 4 with octets 6-34, which are common in templates 4.0 to 4.12.
+func buildProductDefinition(templateNumber int, timeUnit byte, forecastTime uint32) []byte {
+	body := []byte{0, 0, byte(templateNumber >> 8), byte(templateNumber)} // octets 6-9
+	body = append(body, 0, 0, 2, 0, 0, 0, 0, 0)                           // octets 10-17
+	body = append(body, timeUnit)                                         // octet 18
+	body = append(body, 0, 0, 0, 0)                                       // octets 19-22
+	binary.BigEndian.PutUint32(body[len(body)-4:], forecastTime)
+	body = append(body, 1, 0, 0, 0, 0, 0, 255, 0, 0, 0, 0, 0) // octets 23-34: fixed surfaces
+	return body
+}
+
+// part of statistically processed templates from end time of overall time interval.
+func buildStatistics(endTime time.Time) []byte {
+	body := buildTime(endTime)
+	return append(body, 1, 0, 0, 0, 0, 1, 2, 1, 0, 0, 0, 6, 1, 0, 0, 0, 0)
+}
+
+// message with section 1, 4 and other sections which are skipped.
+func buildMessage(sections ...[]byte) []byte {
+	var body []byte
+	body = append(body, buildSection(3, make([]byte, 20))...)
+	for _, section := range sections {
+		body = append(body, section...)
+	}
+	body = append(body, buildSection(5, make([]byte, 10))...)
+	body = append(body, buildSection(6, []byte{255})...)
+	body = append(body, buildSection(7, make([]byte, 100))...)
+	body = append(body, []byte(endSectionMarker)...)
+
+	indicator := []byte{'G', 'R', 'I', 'B', 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint64(indicator[8:], uint64(indicatorSize+len(body)))
+	return append(indicator, body...)
+}
+
+var testReferenceTime = time.Date(2021, 4, 22, 0, 0, 0, 0, time.UTC)
+
+func buildFieldMessage(productDefinition []byte) []byte {
+	return buildMessage(
+		buildSection(1, buildIdentification(testReferenceTime)),
+		buildSection(4, productDefinition),
+	)
+}
+
+func TestReadFields(t *testing.T) {
+	ensemble := append(buildProductDefinition(1, TimeUnitHour, 12), 3, 5, 31)
+	accumulated := append(buildProductDefinition(8, TimeUnitHour, 66),
+		buildStatistics(testReferenceTime.Add(72*time.Hour))...)
+	ensembleAccumulated := append(buildProductDefinition(11, TimeUnitHour, 6), 3, 7, 31)
+	ensembleAccumulated = append(ensembleAccumulated, buildStatistics(testReferenceTime.Add(12*time.Hour))...)
+	derivedAccumulated := append(buildProductDefinition(12, TimeUnitHour, 18), 0, 31)
+	derivedAccumulated = append(derivedAccumulated, buildStatistics(testReferenceTime.Add(24*time.Hour))...)
+
+	tests := []struct {
+		name           string
+		message        []byte
+		templateNumber int
+		forecast       time.Duration
+		number         *int
+		hasEndTime     bool
+	}{
+		{"deterministic", buildFieldMessage(buildProductDefinition(0, TimeUnitHour, 72)), 0, 72 * time.Hour, nil, false},
+		{"minutes", buildFieldMessage(buildProductDefinition(0, TimeUnitMinute, 90)), 0, 90 * time.Minute, nil, false},
+		{"3 hours", buildFieldMessage(buildProductDefinition(0, TimeUnit3Hours, 2)), 0, 6 * time.Hour, nil, false},
+		{"negative", buildFieldMessage(buildProductDefinition(0, TimeUnitHour, 0x80000006)), 0, -6 * time.Hour, nil, false},
+		{"ensemble", buildFieldMessage(ensemble), 1, 12 * time.Hour, intPointer(5), false},
+		{"accumulated", buildFieldMessage(accumulated), 8, 72 * time.Hour, nil, true},
+		{"ensemble accumulated", buildFieldMessage(ensembleAccumulated), 11, 12 * time.Hour, intPointer(7), true},
+		{"derived accumulated", buildFieldMessage(derivedAccumulated), 12, 24 * time.Hour, nil, true},
+	}
+
+	for _, test := range tests {
+		fields, err := ReadFields(bytes.NewReader(test.message))
+		if err != nil {
+			t.Errorf("%s: ReadFields has error: %v", test.name, err)
+			continue
+		}
+		if len(fields) != 1 {
+			t.Errorf("%s: got %d fields, want 1", test.name, len(fields))
+			continue
+		}
+		field := fields[0]
+		if field.TemplateNumber != test.templateNumber {
+			t.Errorf("%s: template number is %d, want %d", test.name, field.TemplateNumber, test.templateNumber)
+		}
+		if !field.ReferenceTime.Equal(testReferenceTime) {
+			t.Errorf("%s: reference time is %v", test.name, field.ReferenceTime)
+		}
+		if field.Discipline != 0 {
+			t.Errorf("%s: discipline is %d", test.name, field.Discipline)
+		}
+		forecast, err := field.Forecast()
+		if err != nil {
+			t.Errorf("%s: Forecast has error: %v", test.name, err)
+		} else if forecast != test.forecast {
+			t.Errorf("%s: forecast is %v, want %v", test.name, forecast, test.forecast)
+		}
+		if (field.EndTime != nil) != test.hasEndTime {
+			t.Errorf("%s: end time is %v", test.name, field.EndTime)
+		}
+		switch {
+		case test.number == nil && field.PerturbationNumber != nil:
+			t.Errorf("%s: perturbation number is %d, want none", test.name, *field.PerturbationNumber)
+		case test.number != nil && field.PerturbationNumber == nil:
+			t.Errorf("%s: perturbation number is not found", test.name)
+		case test.number != nil && *field.PerturbationNumber != *test.number:
+			t.Errorf("%s: perturbation number is %d, want %d", test.name, *field.PerturbationNumber, *test.number)
+		}
+	}
+}
+
+func TestReadFieldsMultipleMessages(t *testing.T) {
+	var content []byte
+	content = append(content, buildFieldMessage(buildProductDefinition(0, TimeUnitHour, 24))...)
+	// unsupported template is ignored.
+	content = append(content, buildFieldMessage(buildProductDefinition(40, TimeUnitHour, 24))...)
+	// message with two fields.
+	content = append(content, buildMessage(
+		buildSection(1, buildIdentification(testReferenceTime)),
+		buildSection(4, buildProductDefinition(0, TimeUnitHour, 48)),
+		buildSection(4, buildProductDefinition(0, TimeUnitDay, 3)),
+	)...)
+
+	fields, err := ReadFields(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("ReadFields has error: %v", err)
+	}
+	var forecasts []time.Duration
+	for _, field := range fields {
+		forecast, err := field.Forecast()
+		if err != nil {
+			t.Fatalf("Forecast has error: %v", err)
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	want := []time.Duration{24 * time.Hour, 48 * time.Hour, 72 * time.Hour}
+	if len(forecasts) != len(want) {
+		t.Fatalf("forecasts are %v, want %v", forecasts, want)
+	}
+	for index := range want {
+		if forecasts[index] != want[index] {
+			t.Errorf("forecasts are %v, want %v", forecasts, want)
+			break
+		}
+	}
+}
+
+func TestReadFieldsError(t *testing.T) {
+	message := buildFieldMessage(buildProductDefinition(0, TimeUnitHour, 24))
+	edition1 := append([]byte{}, message...)
+	edition1[7] = 1
+	sectionOffset := indicatorSize + 25 // after section 3
+	section1 := buildSection(1, buildIdentification(testReferenceTime))
+
+	tests := []struct {
+		name    string
+		content []byte
+		err     string
+	}{
+		{"not grib", append([]byte("GRIX"), message[4:]...), "does not start with GRIB"},
+		{"edition 1", edition1, "edition 1"},
+		{"truncated indicator", message[:10], "section 0"},
+		{"truncated section", message[:sectionOffset+20], "read section"},
+		{"truncated before end", message[:len(message)-2], "read section"},
+		{
+			"short section 1",
+			buildMessage(buildSection(1, make([]byte, 10)), buildSection(4, buildProductDefinition(0, TimeUnitHour, 24))),
+			"section 1 is too short",
+		},
+		{
+			"short section 4",
+			buildMessage(section1, buildSection(4, buildProductDefinition(0, TimeUnitHour, 24)[:20])),
+			"section 4 of template 4.0 is too short",
+		},
+		{
+			"short ensemble section 4",
+			buildMessage(section1, buildSection(4, buildProductDefinition(1, TimeUnitHour, 24))),
+			"section 4 of template 4.1 is too short",
+		},
+		{
+			"short statistics section 4",
+			buildMessage(section1, buildSection(4, append(buildProductDefinition(8, TimeUnitHour, 24), 7, 229))),
+			"section 4 of template 4.8 is too short",
+		},
+		{
+			"section 4 before section 1",
+			buildMessage(buildSection(4, buildProductDefinition(0, TimeUnitHour, 24)), section1),
+			"section 4 is before section 1",
+		},
+		{
+			"invalid section length",
+			buildMessage([]byte{0, 0, 0, 2, 1}),
+			"length of section 1 is invalid",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ReadFields(bytes.NewReader(test.content))
+		if err == nil {
+			t.Errorf("%s: ReadFields should return error", test.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error is %q, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestForecastUnsupportedUnit(t *testing.T) {
+	field := Field{TimeUnit: TimeUnitMonth, ForecastTime: 1}
+	_, err := field.Forecast()
+	if err == nil {
+		t.Errorf("Forecast should return error for unit month")
+	}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
@@ -0,0 +1,32 @@
+package common
+
+// job events
+const (
+	JobEventSubmit = "submit"
+	JobEventCancel = "cancel"
+)
+
+// results of job events
+const (
+	JobResultSucceeded = "succeeded"
+	JobResultFailed    = "failed"
+)
+
+// JobEventData is data of job message, sent when ecFlow submits or cancels a job.
+type JobEventData struct {
+	Event     string `json:"event"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+	Scheduler string `json:"scheduler"`
+	JobID     string `json:"job_id,omitempty"`
+	Queue     string `json:"queue,omitempty"`
+
+	EcflowHost string `json:"ecf_host"`
+	EcflowPort string `json:"ecf_port"`
+	NodeName   string `json:"ecf_name"`
+	TryNo      string `json:"ecf_tryno,omitempty"`
+	Tries      string `json:"ecf_tries,omitempty"`
+	EcfDate    string `json:"ecf_date,omitempty"`
+	EcfJob     string `json:"ecf_job,omitempty"`
+	EcfJobOut  string `json:"ecf_jobout,omitempty"`
+}
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"bufio"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// batch job schedulers
+const (
+	SchedulerSlurm = "slurm"
+	SchedulerPBS   = "pbs"
+	SchedulerLSF   = "lsf"
+)
+
+// JobContext is the context of the job running ecflow_client, collected from environment variables.
+type JobContext struct {
+	Scheduler string `json:"scheduler,omitempty"`
+	JobID     string `json:"job_id,omitempty"`
+	JobName   string `json:"job_name,omitempty"`
+	Queue     string `json:"queue,omitempty"`
+	NodeList  string `json:"node_list,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+	User      string `json:"user,omitempty"`
+	EcfJob    string `json:"ecf_job,omitempty"`
+	EcfJobOut string `json:"ecf_jobout,omitempty"`
+}
+
+// GetJobContext collects job context of Slurm, PBS or LSF, hostname, user and ecFlow job paths.
+func GetJobContext() *JobContext {
+	job := &JobContext{
+		EcfJob:    os.Getenv("ECF_JOB"),
+		EcfJobOut: os.Getenv("ECF_JOBOUT"),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		job.Hostname = hostname
+	}
+	if currentUser, err := user.Current(); err == nil {
+		job.User = currentUser.Username
+	} else {
+		job.User = os.Getenv("USER")
+	}
+
+	switch {
+	case os.Getenv("SLURM_JOB_ID") != "":
+		job.Scheduler = SchedulerSlurm
+		job.JobID = os.Getenv("SLURM_JOB_ID")
+		job.JobName = os.Getenv("SLURM_JOB_NAME")
+		job.Queue = os.Getenv("SLURM_JOB_PARTITION")
+		job.NodeList = firstEnv("SLURM_JOB_NODELIST", "SLURM_NODELIST")
+	case os.Getenv("PBS_JOBID") != "":
+		job.Scheduler = SchedulerPBS
+		job.JobID = os.Getenv("PBS_JOBID")
+		job.JobName = os.Getenv("PBS_JOBNAME")
+		job.Queue = os.Getenv("PBS_QUEUE")
+		job.NodeList = readPBSNodeList(os.Getenv("PBS_NODEFILE"))
+	case os.Getenv("LSB_JOBID") != "":
+		job.Scheduler = SchedulerLSF
+		job.JobID = os.Getenv("LSB_JOBID")
+		job.JobName = os.Getenv("LSB_JOBNAME")
+		job.Queue = os.Getenv("LSB_QUEUE")
+		job.NodeList = strings.Join(uniqueStrings(strings.Fields(os.Getenv("LSB_HOSTS"))), ",")
+	}
+	return job
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// read unique host names in PBS node file, which has one line for each cpu.
+func readPBSNodeList(nodeFile string) string {
+	if nodeFile == "" {
+		return ""
+	}
+	file, err := os.Open(nodeFile)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var hosts []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if host := strings.TrimSpace(scanner.Text()); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return strings.Join(uniqueStrings(hosts), ",")
+}
+
+func uniqueStrings(values []string) []string {
+	var result []string
+	found := make(map[string]bool)
+	for _, value := range values {
+		if found[value] {
+			continue
+		}
+		found[value] = true
+		result = append(result, value)
+	}
+	return result
+}
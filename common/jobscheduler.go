@@ -0,0 +1,172 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// SchedulerShell runs job script in background on current host. Job id is the process id.
+const SchedulerShell = "shell"
+
+var slurmJobIDPattern = regexp.MustCompile(`Submitted batch job (\d+)`)
+var pbsJobIDPattern = regexp.MustCompile(`^\d+(\.\S+)?$`)
+
+// JobScheduler submits and cancels jobs using commands of Slurm, PBS or shell.
+//
+// SubmitCommand and CancelCommand overwrite default commands, such as sbatch and scancel for Slurm.
+type JobScheduler struct {
+	Name          string
+	SubmitCommand string
+	CancelCommand string
+}
+
+func CreateJobScheduler(name string, submitCommand string, cancelCommand string) (*JobScheduler, error) {
+	var defaultSubmitCommand, defaultCancelCommand string
+	switch name {
+	case SchedulerSlurm:
+		defaultSubmitCommand, defaultCancelCommand = "sbatch", "scancel"
+	case SchedulerPBS:
+		defaultSubmitCommand, defaultCancelCommand = "qsub", "qdel"
+	case SchedulerShell:
+		defaultSubmitCommand, defaultCancelCommand = "", ""
+	default:
+		return nil, fmt.Errorf("scheduler is not supported: %s", name)
+	}
+	if submitCommand == "" {
+		submitCommand = defaultSubmitCommand
+	}
+	if cancelCommand == "" {
+		cancelCommand = defaultCancelCommand
+	}
+	return &JobScheduler{
+		Name:          name,
+		SubmitCommand: submitCommand,
+		CancelCommand: cancelCommand,
+	}, nil
+}
+
+// Submit submits job script and returns job id and output of submit command.
+//
+// Queue is passed by -p for Slurm and -q for PBS. Shell scheduler writes output of job into jobOut,
+// and discards it if jobOut is empty.
+func (s *JobScheduler) Submit(jobScript string, queue string, jobOut string, extraArgs []string) (string, string, error) {
+	if s.Name == SchedulerShell && s.SubmitCommand == "" {
+		return submitShellJob(jobScript, jobOut)
+	}
+
+	var args []string
+	if queue != "" {
+		switch s.Name {
+		case SchedulerSlurm:
+			args = append(args, "-p", queue)
+		case SchedulerPBS:
+			args = append(args, "-q", queue)
+		}
+	}
+	args = append(args, extraArgs...)
+	args = append(args, jobScript)
+
+	output, err := runSchedulerCommand(s.SubmitCommand, args)
+	if err != nil {
+		return "", output, err
+	}
+	jobID, err := ParseJobID(s.Name, output)
+	return jobID, output, err
+}
+
+// Cancel cancels job by id and returns output of cancel command.
+// Shell scheduler sends SIGTERM to the process group or the process.
+func (s *JobScheduler) Cancel(jobID string) (string, error) {
+	if s.Name == SchedulerShell && s.CancelCommand == "" {
+		pid, err := strconv.Atoi(jobID)
+		if err != nil {
+			return "", fmt.Errorf("job id is not a process id: %s", jobID)
+		}
+		// kill with 0, 1 or negative pid signals the caller's group, init or all processes of user.
+		if pid <= 1 {
+			return "", fmt.Errorf("job id is not a valid process id: %s", jobID)
+		}
+		// job started by shell scheduler is a process group leader, kill the whole group.
+		err = syscall.Kill(-pid, syscall.SIGTERM)
+		if err != nil {
+			err = syscall.Kill(pid, syscall.SIGTERM)
+		}
+		if err != nil {
+			return "", fmt.Errorf("kill process %d has error: %v", pid, err)
+		}
+		return "", nil
+	}
+	return runSchedulerCommand(s.CancelCommand, []string{jobID})
+}
+
+// ParseJobID gets job id from output of submit command, such as
+// "Submitted batch job 12345" or "12345;cluster" of sbatch, and "12345.server" of qsub.
+func ParseJobID(scheduler string, output string) (string, error) {
+	output = strings.TrimSpace(output)
+	switch scheduler {
+	case SchedulerSlurm:
+		if match := slurmJobIDPattern.FindStringSubmatch(output); match != nil {
+			return match[1], nil
+		}
+		// sbatch --parsable
+		tokens := strings.Split(output, ";")
+		if _, err := strconv.Atoi(tokens[0]); err == nil {
+			return tokens[0], nil
+		}
+	case SchedulerPBS:
+		for _, line := range strings.Split(output, "\n") {
+			line = strings.TrimSpace(line)
+			if pbsJobIDPattern.MatchString(line) {
+				return line, nil
+			}
+		}
+	case SchedulerShell:
+		if output != "" {
+			return strings.Fields(output)[0], nil
+		}
+	}
+	return "", fmt.Errorf("can't find job id in output of %s: %s", scheduler, output)
+}
+
+func runSchedulerCommand(program string, args []string) (string, error) {
+	var output bytes.Buffer
+	command := exec.Command(program, args...)
+	command.Stdout = &output
+	command.Stderr = &output
+	err := command.Run()
+	if err != nil {
+		return output.String(), fmt.Errorf("run %s has error: %v", program, err)
+	}
+	return output.String(), nil
+}
+
+// run job script in a new session with output redirected to jobOut, and don't wait for it.
+// Output is discarded if jobOut is empty, because job id is printed to stdout of current process.
+func submitShellJob(jobScript string, jobOut string) (string, string, error) {
+	if jobOut == "" {
+		jobOut = os.DevNull
+	}
+	output, err := os.OpenFile(jobOut, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", "", fmt.Errorf("open job output has error: %v", err)
+	}
+	defer output.Close()
+
+	command := exec.Command(jobScript)
+	command.Stdout = output
+	command.Stderr = output
+	command.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	err = command.Start()
+	if err != nil {
+		return "", "", fmt.Errorf("start job has error: %v", err)
+	}
+	jobID := strconv.Itoa(command.Process.Pid)
+	command.Process.Release()
+	return jobID, "", nil
+}
@@ -0,0 +1,226 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// write a fake submitter script into dir and return its path.
+func writeFakeCommand(t *testing.T, dir string, name string, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755)
+	if err != nil {
+		t.Fatalf("write fake command has error: %v", err)
+	}
+	return path
+}
+
+func TestParseJobID(t *testing.T) {
+	tests := []struct {
+		scheduler string
+		output    string
+		jobID     string
+		hasError  bool
+	}{
+		{SchedulerSlurm, "Submitted batch job 12345\n", "12345", false},
+		{SchedulerSlurm, "12345;cluster\n", "12345", false},
+		{SchedulerSlurm, "12345\n", "12345", false},
+		{SchedulerSlurm, "sbatch: error: invalid partition\n", "", true},
+		{SchedulerSlurm, "", "", true},
+		{SchedulerPBS, "12345.server\n", "12345.server", false},
+		{SchedulerPBS, "qsub: warning\n6789\n", "6789", false},
+		{SchedulerPBS, "qsub: Unknown queue\n", "", true},
+		{SchedulerShell, "4321\n", "4321", false},
+		{SchedulerShell, "", "", true},
+		{"lsf", "Job <1> is submitted", "", true},
+	}
+	for _, test := range tests {
+		jobID, err := ParseJobID(test.scheduler, test.output)
+		if (err != nil) != test.hasError {
+			t.Errorf("ParseJobID(%s, %q) error = %v, want error %v", test.scheduler, test.output, err, test.hasError)
+			continue
+		}
+		if jobID != test.jobID {
+			t.Errorf("ParseJobID(%s, %q) = %s, want %s", test.scheduler, test.output, jobID, test.jobID)
+		}
+	}
+}
+
+func TestCreateJobScheduler(t *testing.T) {
+	scheduler, err := CreateJobScheduler(SchedulerSlurm, "", "")
+	if err != nil {
+		t.Fatalf("CreateJobScheduler has error: %v", err)
+	}
+	if scheduler.SubmitCommand != "sbatch" || scheduler.CancelCommand != "scancel" {
+		t.Errorf("default commands of slurm are %s and %s", scheduler.SubmitCommand, scheduler.CancelCommand)
+	}
+
+	scheduler, err = CreateJobScheduler(SchedulerPBS, "/opt/pbs/qsub", "")
+	if err != nil {
+		t.Fatalf("CreateJobScheduler has error: %v", err)
+	}
+	if scheduler.SubmitCommand != "/opt/pbs/qsub" || scheduler.CancelCommand != "qdel" {
+		t.Errorf("commands of pbs are %s and %s", scheduler.SubmitCommand, scheduler.CancelCommand)
+	}
+
+	_, err = CreateJobScheduler("lsf", "", "")
+	if err == nil {
+		t.Errorf("CreateJobScheduler should return error for unsupported scheduler")
+	}
+}
+
+func TestJobSchedulerSubmit(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	tests := []struct {
+		name      string
+		scheduler string
+		command   string
+		queue     string
+		extraArgs []string
+		jobID     string
+		args      string
+		hasError  bool
+	}{
+		{
+			name:      "sbatch",
+			scheduler: SchedulerSlurm,
+			command:   `echo "$@" > ` + argsFile + `; echo "Submitted batch job 1001"`,
+			queue:     "serial",
+			extraArgs: []string{"--nodes=1"},
+			jobID:     "1001",
+			args:      "-p serial --nodes=1 job.sh",
+		},
+		{
+			name:      "sbatch parsable",
+			scheduler: SchedulerSlurm,
+			command:   `echo "$@" > ` + argsFile + `; echo "1002;cluster"`,
+			jobID:     "1002",
+			args:      "job.sh",
+		},
+		{
+			name:      "qsub",
+			scheduler: SchedulerPBS,
+			command:   `echo "$@" > ` + argsFile + `; echo "1003.pbs-server"`,
+			queue:     "normal",
+			jobID:     "1003.pbs-server",
+			args:      "-q normal job.sh",
+		},
+		{
+			name:      "sbatch fails",
+			scheduler: SchedulerSlurm,
+			command:   `echo "sbatch: error: Batch job submission failed" >&2; exit 1`,
+			hasError:  true,
+		},
+		{
+			name:      "sbatch without job id",
+			scheduler: SchedulerSlurm,
+			command:   `echo "sbatch: something happened"`,
+			hasError:  true,
+		},
+	}
+
+	for index, test := range tests {
+		os.Remove(argsFile)
+		command := writeFakeCommand(t, dir, "submit"+strconv.Itoa(index), test.command)
+		scheduler, err := CreateJobScheduler(test.scheduler, command, "")
+		if err != nil {
+			t.Fatalf("%s: CreateJobScheduler has error: %v", test.name, err)
+		}
+
+		jobID, output, err := scheduler.Submit("job.sh", test.queue, "", test.extraArgs)
+		if test.hasError {
+			if err == nil {
+				t.Errorf("%s: Submit should return error, job id is %s", test.name, jobID)
+			}
+			if output == "" {
+				t.Errorf("%s: output of submit command should be returned with error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Submit has error: %v", test.name, err)
+			continue
+		}
+		if jobID != test.jobID {
+			t.Errorf("%s: job id is %s, want %s", test.name, jobID, test.jobID)
+		}
+		args, _ := ioutil.ReadFile(argsFile)
+		if strings.TrimSpace(string(args)) != test.args {
+			t.Errorf("%s: args of submit command are %q, want %q", test.name, strings.TrimSpace(string(args)), test.args)
+		}
+	}
+}
+
+func TestJobSchedulerCancel(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+
+	command := writeFakeCommand(t, dir, "scancel", `echo "$@" > `+argsFile)
+	scheduler, _ := CreateJobScheduler(SchedulerSlurm, "", command)
+	_, err := scheduler.Cancel("1001")
+	if err != nil {
+		t.Fatalf("Cancel has error: %v", err)
+	}
+	args, _ := ioutil.ReadFile(argsFile)
+	if strings.TrimSpace(string(args)) != "1001" {
+		t.Errorf("args of cancel command are %q, want 1001", args)
+	}
+
+	command = writeFakeCommand(t, dir, "scancel-fail", `echo "scancel: error: Invalid job id" >&2; exit 1`)
+	scheduler, _ = CreateJobScheduler(SchedulerSlurm, "", command)
+	output, err := scheduler.Cancel("1001")
+	if err == nil {
+		t.Errorf("Cancel should return error when cancel command fails")
+	}
+	if !strings.Contains(output, "Invalid job id") {
+		t.Errorf("output of cancel command is %q", output)
+	}
+
+	scheduler, _ = CreateJobScheduler(SchedulerShell, "", "")
+	for _, jobID := range []string{"not-a-pid", "0", "1", "-1", "-1234"} {
+		_, err = scheduler.Cancel(jobID)
+		if err == nil {
+			t.Errorf("Cancel of shell scheduler should return error for invalid process id %s", jobID)
+		}
+	}
+}
+
+func TestJobSchedulerSubmitShell(t *testing.T) {
+	dir := t.TempDir()
+	jobOut := filepath.Join(dir, "job.out")
+	jobScript := writeFakeCommand(t, dir, "job.sh", `echo "job is running"`)
+
+	scheduler, _ := CreateJobScheduler(SchedulerShell, "", "")
+	jobID, _, err := scheduler.Submit(jobScript, "", jobOut, nil)
+	if err != nil {
+		t.Fatalf("Submit has error: %v", err)
+	}
+	if _, err = strconv.Atoi(jobID); err != nil {
+		t.Errorf("job id of shell scheduler should be a process id: %s", jobID)
+	}
+
+	// job is not waited by Submit.
+	var content []byte
+	for i := 0; i < 50; i++ {
+		content, _ = ioutil.ReadFile(jobOut)
+		if len(content) > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if strings.TrimSpace(string(content)) != "job is running" {
+		t.Errorf("job output is %q", content)
+	}
+
+	_, _, err = scheduler.Submit(filepath.Join(dir, "missing.sh"), "", "", nil)
+	if err == nil {
+		t.Errorf("Submit should return error for missing job script")
+	}
+}
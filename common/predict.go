@@ -0,0 +1,24 @@
+package common
+
+import "time"
+
+// Methods of prediction.
+const (
+	PredictMethodHistory = "history" // statistics of history runs
+	PredictMethodModel   = "model"   // machine learning or other models
+	PredictMethodManual  = "manual"  // set by operators
+)
+
+// PredictData is predicted finish time of a task or a product in a run of a system.
+// One of Task and Product is set.
+type PredictData struct {
+	System    string    `json:"system"`     // system name, such as grapes_gfs_gmf
+	StartTime time.Time `json:"start_time"` // start time of the run, YYYYMMDDHH
+
+	Task    string `json:"task,omitempty"`    // ecFlow node path, such as /grapes_gfs_gmf/00/model
+	Product string `json:"product,omitempty"` // product, such as grib2.orig.024h
+
+	PredictedTime time.Time `json:"predicted_time"`       // predicted finish time
+	Method        string    `json:"method"`               // history, model, manual, ...
+	Confidence    *float64  `json:"confidence,omitempty"` // confidence in [0, 1]
+}
@@ -1,11 +1,51 @@
 package common
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type ProductionEvent string
 
+// events in the life of a product, in usual order.
+const (
+	ProductionEventGeneration ProductionEvent = "generation"
+	ProductionEventCheck      ProductionEvent = "check"
+	ProductionEventConversion ProductionEvent = "conversion"
+	ProductionEventStorage    ProductionEvent = "storage"
+	ProductionEventArchive    ProductionEvent = "archive"
+	ProductionEventTransfer   ProductionEvent = "transfer"
+	ProductionEventDeletion   ProductionEvent = "deletion"
+)
+
+var productionEvents = []ProductionEvent{
+	ProductionEventGeneration,
+	ProductionEventCheck,
+	ProductionEventConversion,
+	ProductionEventStorage,
+	ProductionEventArchive,
+	ProductionEventTransfer,
+	ProductionEventDeletion,
+}
+
+// ParseProductionEvent returns error if event is not a known production event.
+func ParseProductionEvent(event string) (ProductionEvent, error) {
+	var names []string
+	for _, e := range productionEvents {
+		if string(e) == event {
+			return e, nil
+		}
+		names = append(names, string(e))
+	}
+	return "", fmt.Errorf("production event is not valid: %s, valid values: %s", event, strings.Join(names, ", "))
+}
+
+// Results of check event.
 const (
-	ProductionEventStorage ProductionEvent = "storage"
+	CheckResultPassed  = "passed"
+	CheckResultWarning = "warning"
+	CheckResultFailed  = "failed"
 )
 
 type ProductionStream string
@@ -13,6 +53,8 @@ type ProductionStream string
 const (
 	ProductionStreamOperation ProductionStream = "oper"
 	ProductionStreamEPS       ProductionStream = "eps"
+	ProductionStreamHindcast  ProductionStream = "hindcast"
+	ProductionStreamRegional  ProductionStream = "regional"
 )
 
 type ProductionType string
@@ -32,7 +74,7 @@ const (
 
 type ProductionInfo struct {
 	System string           `json:"system"` // system name: grapes_gfs_gmf, grapes_gfs_gda
-	Stream ProductionStream `json:"stream"` // stream: oper, eps, ...
+	Stream ProductionStream `json:"stream"` // stream: oper, eps, hindcast, regional, ...
 	Type   ProductionType   `json:"type"`   // production type: grib2
 	Name   ProductionName   `json:"name"`   // production name, orig, ...
 }
@@ -40,27 +82,115 @@ type ProductionInfo struct {
 type ProductionEventStatus struct {
 	Event  ProductionEvent `json:"event"`  // production event, storage
 	Status EventStatus     `json:"status"` // unknown, complete, queued, aborted, submitted, active, suspended
+	ProductionEventDetail
+}
+
+// ProductionEventDetail contains optional fields of some events. Each field belongs to one event.
+type ProductionEventDetail struct {
+	CheckResult     string `json:"check_result,omitempty"`     // check: passed, warning or failed
+	CheckMessage    string `json:"check_message,omitempty"`    // check
+	SourceFormat    string `json:"source_format,omitempty"`    // conversion, such as grib1
+	TargetFormat    string `json:"target_format,omitempty"`    // conversion, such as grib2
+	ArchivePath     string `json:"archive_path,omitempty"`     // archive
+	DestinationHost string `json:"destination_host,omitempty"` // transfer
+	DestinationPath string `json:"destination_path,omitempty"` // transfer
+}
+
+type eventDetailField struct {
+	name     string
+	event    ProductionEvent
+	required bool
+	value    func(d *ProductionEventDetail) string
+}
+
+var eventDetailFields = []eventDetailField{
+	{"check_result", ProductionEventCheck, true, func(d *ProductionEventDetail) string { return d.CheckResult }},
+	{"check_message", ProductionEventCheck, false, func(d *ProductionEventDetail) string { return d.CheckMessage }},
+	{"source_format", ProductionEventConversion, false, func(d *ProductionEventDetail) string { return d.SourceFormat }},
+	{"target_format", ProductionEventConversion, true, func(d *ProductionEventDetail) string { return d.TargetFormat }},
+	{"archive_path", ProductionEventArchive, true, func(d *ProductionEventDetail) string { return d.ArchivePath }},
+	{"destination_host", ProductionEventTransfer, true, func(d *ProductionEventDetail) string { return d.DestinationHost }},
+	{"destination_path", ProductionEventTransfer, false, func(d *ProductionEventDetail) string { return d.DestinationPath }},
+}
+
+// Validate checks that required fields of event are set, and fields of other events are not set.
+func (d *ProductionEventDetail) Validate(event ProductionEvent) error {
+	for _, field := range eventDetailFields {
+		value := field.value(d)
+		if field.event != event && value != "" {
+			return fmt.Errorf("%s is only used by %s event", field.name, field.event)
+		}
+		if field.event == event && field.required && value == "" {
+			return fmt.Errorf("%s is required by %s event", field.name, field.event)
+		}
+	}
+	if d.CheckResult != "" && d.CheckResult != CheckResultPassed &&
+		d.CheckResult != CheckResultWarning && d.CheckResult != CheckResultFailed {
+		return fmt.Errorf("check result should be %s, %s or %s: %s",
+			CheckResultPassed, CheckResultWarning, CheckResultFailed, d.CheckResult)
+	}
+	return nil
 }
 
 type OperationProductionProperties struct {
 	StartTime    time.Time `json:"start_time"`    // start time, YYYYMMDDHH
 	ForecastTime string    `json:"forecast_time"` // time duration, such as 3h, 12h, 120h
+	// parsed from ForecastTime, empty in messages of old clients.
+	ForecastHour *float64   `json:"forecast_hour,omitempty"`
+	ValidTime    *time.Time `json:"valid_time,omitempty"` // start time + forecast time
 }
 
 type OperationProductionData struct {
 	ProductionInfo
 	OperationProductionProperties
 	ProductionEventStatus
+	ProductionFiles
 }
 
 type EpsProductionProperties struct {
 	StartTime    time.Time `json:"start_time"`    // start time, YYYYMMDDHH
 	ForecastTime string    `json:"forecast_time"` // time duration, such as 3h, 12h, 120h
-	Number       int       `json:"number"`
+	// parsed from ForecastTime, empty in messages of old clients.
+	ForecastHour *float64   `json:"forecast_hour,omitempty"`
+	ValidTime    *time.Time `json:"valid_time,omitempty"` // start time + forecast time
+	Number       int        `json:"number"`
 }
 
 type EpsProductionData struct {
 	ProductionInfo
 	EpsProductionProperties
 	ProductionEventStatus
+	ProductionFiles
+}
+
+type HindcastProductionProperties struct {
+	StartTime     time.Time `json:"start_time"`     // start time, YYYYMMDDHH
+	ReferenceDate time.Time `json:"reference_date"` // date of the forecast which hindcast is for, YYYYMMDD
+	ForecastTime  string    `json:"forecast_time"`  // time duration, such as 3h, 12h, 120h
+	// parsed from ForecastTime, empty in messages of old clients.
+	ForecastHour *float64   `json:"forecast_hour,omitempty"`
+	ValidTime    *time.Time `json:"valid_time,omitempty"` // start time + forecast time
+}
+
+type HindcastProductionData struct {
+	ProductionInfo
+	HindcastProductionProperties
+	ProductionEventStatus
+	ProductionFiles
+}
+
+type RegionalProductionProperties struct {
+	StartTime    time.Time `json:"start_time"`    // start time, YYYYMMDDHH
+	ForecastTime string    `json:"forecast_time"` // time duration, such as 3h, 12h, 120h
+	// parsed from ForecastTime, empty in messages of old clients.
+	ForecastHour *float64   `json:"forecast_hour,omitempty"`
+	ValidTime    *time.Time `json:"valid_time,omitempty"` // start time + forecast time
+	DomainID     string     `json:"domain_id"`            // nested domain, such as d01, d02
+}
+
+type RegionalProductionData struct {
+	ProductionInfo
+	RegionalProductionProperties
+	ProductionEventStatus
+	ProductionFiles
 }
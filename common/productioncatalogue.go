@@ -0,0 +1,245 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modes of catalogue validation. Invalid messages are rejected in strict mode,
+// and are only logged in warn mode.
+const (
+	CatalogueModeStrict = "strict"
+	CatalogueModeWarn   = "warn"
+)
+
+// ProductCatalogue lists valid values in production messages. It is loaded from a YAML file:
+//
+//	events: [storage]
+//	statuses: [complete, aborted]
+//	systems:
+//	  - name: grapes_gfs_gmf
+//	    streams: [oper]
+//	    products:
+//	      - type: grib2
+//	        names: [orig, ne]
+//	        forecast_hours: ["0-240/3"]
+//
+// Empty lists accept any value, except that status unknown is accepted only when it is listed.
+// Forecast hours are hours or ranges start-end/step.
+type ProductCatalogue struct {
+	Events   []string          `yaml:"events"`
+	Statuses []string          `yaml:"statuses"`
+	Systems  []CatalogueSystem `yaml:"systems"`
+}
+
+type CatalogueSystem struct {
+	Name     string             `yaml:"name"`
+	Streams  []string           `yaml:"streams"`
+	Products []CatalogueProduct `yaml:"products"`
+}
+
+type CatalogueProduct struct {
+	Type          string   `yaml:"type"`
+	Names         []string `yaml:"names"`
+	ForecastHours []string `yaml:"forecast_hours"`
+
+	forecastHours map[int]bool
+}
+
+// fields of production data which are checked by catalogue.
+type catalogueFields struct {
+	ProductionInfo
+	ProductionEventStatus
+	ForecastTime string `json:"forecast_time"`
+}
+
+func LoadProductCatalogue(path string) (*ProductCatalogue, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read catalogue has error: %v", err)
+	}
+
+	var catalogue ProductCatalogue
+	err = yaml.UnmarshalStrict(content, &catalogue)
+	if err != nil {
+		return nil, fmt.Errorf("parse catalogue %s has error: %v", path, err)
+	}
+
+	for _, status := range catalogue.Statuses {
+		if _, err = ParseEventStatus(status); err != nil {
+			return nil, fmt.Errorf("catalogue %s: %v", path, err)
+		}
+	}
+	for systemIndex := range catalogue.Systems {
+		system := &catalogue.Systems[systemIndex]
+		for productIndex := range system.Products {
+			product := &system.Products[productIndex]
+			product.forecastHours, err = parseHourSet(product.ForecastHours)
+			if err != nil {
+				return nil, fmt.Errorf("catalogue %s: system %s type %s: %v",
+					path, system.Name, product.Type, err)
+			}
+		}
+	}
+	return &catalogue, nil
+}
+
+// CheckCatalogueMode returns error if mode is neither strict nor warn.
+func CheckCatalogueMode(mode string) error {
+	if mode != CatalogueModeStrict && mode != CatalogueModeWarn {
+		return fmt.Errorf("catalogue mode should be %s or %s: %s", CatalogueModeStrict, CatalogueModeWarn, mode)
+	}
+	return nil
+}
+
+// ValidateMessage checks data of a production message.
+func (c *ProductCatalogue) ValidateMessage(message EventMessage) error {
+	return c.ValidateData(message.Data)
+}
+
+// ValidateData checks production data, such as OperationProductionData or data unmarshalled from JSON,
+// and returns an error with all problems found.
+func (c *ProductCatalogue) ValidateData(data interface{}) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal data has error: %v", err)
+	}
+	var fields catalogueFields
+	err = json.Unmarshal(content, &fields)
+	if err != nil {
+		return fmt.Errorf("data is not production data: %v", err)
+	}
+
+	problems := c.check(fields)
+	if len(problems) > 0 {
+		return fmt.Errorf("production data is not valid in catalogue: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (c *ProductCatalogue) check(fields catalogueFields) []string {
+	var problems []string
+	if len(c.Events) > 0 && !containsString(c.Events, string(fields.Event)) {
+		problems = append(problems, fmt.Sprintf("event %s is unknown", fields.Event))
+	}
+	if !c.isValidStatus(fields.Status) {
+		problems = append(problems, fmt.Sprintf("status %s is not allowed", fields.Status))
+	}
+
+	system := c.findSystem(fields.System)
+	if system == nil {
+		return append(problems, fmt.Sprintf("system %s is unknown", fields.System))
+	}
+	if len(system.Streams) > 0 && !containsString(system.Streams, string(fields.Stream)) {
+		problems = append(problems, fmt.Sprintf("stream %s is unknown for system %s", fields.Stream, system.Name))
+	}
+
+	var product *CatalogueProduct
+	typeFound := false
+	for index := range system.Products {
+		current := &system.Products[index]
+		if current.Type != string(fields.Type) {
+			continue
+		}
+		typeFound = true
+		if len(current.Names) == 0 || containsString(current.Names, string(fields.Name)) {
+			product = current
+			break
+		}
+	}
+	if len(system.Products) > 0 && !typeFound {
+		return append(problems, fmt.Sprintf("type %s is unknown for system %s", fields.Type, system.Name))
+	}
+	if len(system.Products) > 0 && product == nil {
+		return append(problems, fmt.Sprintf("name %s is unknown for type %s", fields.Name, fields.Type))
+	}
+
+	if product != nil && len(product.forecastHours) > 0 {
+		hour, err := parseForecastHour(fields.ForecastTime)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if !product.forecastHours[hour] {
+			problems = append(problems, fmt.Sprintf("forecast time %s is not in forecast hours of %s %s",
+				fields.ForecastTime, fields.Type, fields.Name))
+		}
+	}
+	return problems
+}
+
+func (c *ProductCatalogue) findSystem(name string) *CatalogueSystem {
+	for index := range c.Systems {
+		if c.Systems[index].Name == name {
+			return &c.Systems[index]
+		}
+	}
+	return nil
+}
+
+func (c *ProductCatalogue) isValidStatus(status EventStatus) bool {
+	if len(c.Statuses) == 0 {
+		return status > Unknown && status <= Suspended
+	}
+	return containsString(c.Statuses, status.String())
+}
+
+// parse forecast hours such as 12, 0-240/3 into a set.
+func parseHourSet(items []string) (map[int]bool, error) {
+	hours := make(map[int]bool)
+	for _, item := range items {
+		rangePart := item
+		step := 1
+		var err error
+		if index := strings.Index(item, "/"); index != -1 {
+			rangePart = item[:index]
+			step, err = strconv.Atoi(item[index+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("step of forecast hours %s is invalid", item)
+			}
+		}
+		tokens := strings.Split(rangePart, "-")
+		if len(tokens) > 2 {
+			return nil, fmt.Errorf("forecast hours should be start-end/step: %s", item)
+		}
+		start, err := strconv.Atoi(tokens[0])
+		if err != nil {
+			return nil, fmt.Errorf("forecast hours %s is invalid: %v", item, err)
+		}
+		end := start
+		if len(tokens) == 2 {
+			end, err = strconv.Atoi(tokens[1])
+			if err != nil {
+				return nil, fmt.Errorf("forecast hours %s is invalid: %v", item, err)
+			}
+		}
+		for hour := start; hour <= end; hour += step {
+			hours[hour] = true
+		}
+	}
+	return hours, nil
+}
+
+// parse forecast time such as 012h, 1d or 12 into hours.
+func parseForecastHour(forecastTime string) (int, error) {
+	duration, err := ParseForecastTime(forecastTime)
+	if err != nil {
+		return 0, err
+	}
+	if duration%time.Hour != 0 {
+		return 0, fmt.Errorf("forecast time %s is not whole hours", forecastTime)
+	}
+	return int(duration / time.Hour), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package common
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checksum algorithms of production files.
+const (
+	ChecksumNone   = "none"
+	ChecksumMD5    = "md5"
+	ChecksumSHA256 = "sha256"
+)
+
+// ProductionFile is metadata of a file of a product, used in storage events.
+type ProductionFile struct {
+	Path              string    `json:"path"` // absolute path
+	Size              int64     `json:"size"`
+	ModifyTime        time.Time `json:"modify_time"`
+	Checksum          string    `json:"checksum,omitempty"` // hex string
+	ChecksumAlgorithm string    `json:"checksum_algorithm,omitempty"`
+}
+
+// ProductionFiles is embedded in production data. Files is empty if no file is set.
+type ProductionFiles struct {
+	Files []ProductionFile `json:"files,omitempty"`
+}
+
+// CheckChecksumAlgorithm returns error if algorithm is not supported.
+func CheckChecksumAlgorithm(algorithm string) error {
+	switch algorithm {
+	case ChecksumNone, ChecksumMD5, ChecksumSHA256:
+		return nil
+	default:
+		return fmt.Errorf("checksum algorithm should be %s, %s or %s: %s",
+			ChecksumMD5, ChecksumSHA256, ChecksumNone, algorithm)
+	}
+}
+
+// GetProductionFile gets metadata of file at path. Checksum is computed by reading file in stream,
+// so that large files are not loaded into memory.
+func GetProductionFile(path string, algorithm string) (ProductionFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ProductionFile{}, fmt.Errorf("get absolute path of %s has error: %v", path, err)
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return ProductionFile{}, fmt.Errorf("open file has error: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return ProductionFile{}, fmt.Errorf("stat file %s has error: %v", absPath, err)
+	}
+	if info.IsDir() {
+		return ProductionFile{}, fmt.Errorf("path is a directory: %s", absPath)
+	}
+
+	productionFile := ProductionFile{
+		Path:       absPath,
+		Size:       info.Size(),
+		ModifyTime: info.ModTime(),
+	}
+
+	var checksumHash hash.Hash
+	switch algorithm {
+	case ChecksumNone, "":
+		return productionFile, nil
+	case ChecksumMD5:
+		checksumHash = md5.New()
+	case ChecksumSHA256:
+		checksumHash = sha256.New()
+	default:
+		return ProductionFile{}, CheckChecksumAlgorithm(algorithm)
+	}
+
+	_, err = io.Copy(checksumHash, file)
+	if err != nil {
+		return ProductionFile{}, fmt.Errorf("read file %s has error: %v", absPath, err)
+	}
+	productionFile.Checksum = hex.EncodeToString(checksumHash.Sum(nil))
+	productionFile.ChecksumAlgorithm = algorithm
+	return productionFile, nil
+}
+
+// GetProductionFiles gets metadata of files in paths and files matched by glob patterns.
+// Pattern matching no file is an error. Duplicate files are listed once.
+func GetProductionFiles(paths []string, patterns []string, algorithm string) ([]ProductionFile, error) {
+	allPaths := append([]string{}, paths...)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("glob pattern %s has error: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no file matches pattern: %s", pattern)
+		}
+		allPaths = append(allPaths, matches...)
+	}
+
+	// remove duplicate paths before computing checksums, so that each file is read once.
+	var uniquePaths []string
+	found := make(map[string]bool)
+	for _, path := range allPaths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("get absolute path of %s has error: %v", path, err)
+		}
+		if found[absPath] {
+			continue
+		}
+		found[absPath] = true
+		uniquePaths = append(uniquePaths, absPath)
+	}
+
+	var files []ProductionFile
+	for _, path := range uniquePaths {
+		file, err := GetProductionFile(path, algorithm)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
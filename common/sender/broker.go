@@ -4,11 +4,17 @@ import (
 	"context"
 	"fmt"
 	pb "github.com/nwpc-oper/nwpc-message-client/common/messagebroker"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"time"
 )
 
+// ErrorNoInvalidMessage is the error number in response when message is rejected by broker,
+// such as an oversized message.
+const ErrorNoInvalidMessage = 2
+
 type BrokerSender struct {
 	BrokerAddress string
 	BrokerTryNo   int
@@ -16,6 +22,11 @@ type BrokerSender struct {
 }
 
 func (s *BrokerSender) SendMessage(message []byte) error {
+	return s.SendMessages([][]byte{message})
+}
+
+// SendMessages sends messages to broker using one connection. Each message has its own tries.
+func (s *BrokerSender) SendMessages(messages [][]byte) error {
 	var opts []grpc.DialOption
 	opts = append(opts, grpc.WithInsecure())
 	conn, err := grpc.Dial(s.BrokerAddress, opts...)
@@ -25,22 +36,44 @@ func (s *BrokerSender) SendMessage(message []byte) error {
 
 	defer conn.Close()
 
+	for index, message := range messages {
+		err = s.sendMessageWithTries(conn, message)
+		if err != nil {
+			if len(messages) > 1 {
+				return fmt.Errorf("send message %d of %d has error: %v", index+1, len(messages), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BrokerSender) sendMessageWithTries(conn *grpc.ClientConn, message []byte) error {
+	var err error
 	currentCount := 0
 	totalCount := 2
 	if s.BrokerTryNo == 0 {
 		totalCount = 1
 	}
 
+	// same id is used in all tries, so that broker can drop duplicate messages.
+	messageID := NewMessageID()
+	identity := ClientIdentityMetadata()
+	// all tries belong to one trace, which is continued by broker and consumers.
+	identity.Set(tracing.TraceparentHeader, tracing.NewSpanContext().Traceparent())
+
 	successful := false
 	for currentCount < totalCount {
 		currentCount += 1
 		client := pb.NewMessageBrokerClient(conn)
 
 		timeLimit := time.Second * time.Duration(currentCount*2)
-		ctx, cancel := context.WithTimeout(context.Background(), timeLimit)
+		ctx, cancel := context.WithTimeout(
+			metadata.NewOutgoingContext(context.Background(), identity), timeLimit)
 		defer cancel()
 
-		response, err := client.SendRabbitMQMessage(
+		var response *pb.Response
+		response, err = client.SendRabbitMQMessage(
 			ctx,
 			&pb.RabbitMQMessage{
 				Target: &pb.RabbitMQTarget{
@@ -50,6 +83,7 @@ func (s *BrokerSender) SendMessage(message []byte) error {
 				},
 				Message: &pb.Message{
 					Data: message,
+					Id:   messageID,
 				},
 			},
 		)
@@ -68,8 +102,19 @@ func (s *BrokerSender) SendMessage(message []byte) error {
 				"event":     "send",
 			}).Warningf("send message return error code... try %d:  %d: %s",
 				currentCount, response.ErrorNo, response.ErrorMessage)
+			err = fmt.Errorf("error code %d: %s", response.ErrorNo, response.ErrorMessage)
+			if response.ErrorNo == ErrorNoInvalidMessage {
+				// rejected message is never accepted, so it is not sent again.
+				return fmt.Errorf("message is rejected by broker: %v", err)
+			}
 			continue
 		}
+		if response.Duplicate {
+			log.WithFields(log.Fields{
+				"component": "sender-broker",
+				"event":     "send",
+			}).Infof("message has been received by broker... try %d: %s", currentCount, messageID)
+		}
 		successful = true
 		break
 	}
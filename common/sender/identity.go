@@ -0,0 +1,47 @@
+package sender
+
+import (
+	"google.golang.org/grpc/metadata"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// gRPC metadata keys of client identity.
+const (
+	MetadataClientHostname = "nwpc-client-hostname"
+	MetadataClientUser     = "nwpc-client-user"
+	MetadataEcflowHost     = "nwpc-ecflow-host"
+	MetadataEcflowPort     = "nwpc-ecflow-port"
+	MetadataEcflowSuite    = "nwpc-ecflow-suite"
+)
+
+// ClientIdentityMetadata returns identity of current client: hostname, user,
+// and ecFlow host, port and suite from ECF_* environment variables.
+func ClientIdentityMetadata() metadata.MD {
+	md := metadata.MD{}
+	if hostname, err := os.Hostname(); err == nil {
+		md.Set(MetadataClientHostname, hostname)
+	}
+	if currentUser, err := user.Current(); err == nil {
+		md.Set(MetadataClientUser, currentUser.Username)
+	} else if userName := os.Getenv("USER"); userName != "" {
+		md.Set(MetadataClientUser, userName)
+	}
+	if ecflowHost := os.Getenv("ECF_HOST"); ecflowHost != "" {
+		md.Set(MetadataEcflowHost, ecflowHost)
+	}
+	if ecflowPort := os.Getenv("ECF_PORT"); ecflowPort != "" {
+		md.Set(MetadataEcflowPort, ecflowPort)
+	}
+	if suite := getSuiteName(os.Getenv("ECF_NAME")); suite != "" {
+		md.Set(MetadataEcflowSuite, suite)
+	}
+	return md
+}
+
+// get suite name from node path, such as grapes_gfs_gmf from /grapes_gfs_gmf/00/post.
+func getSuiteName(nodePath string) string {
+	tokens := strings.Split(strings.TrimPrefix(nodePath, "/"), "/")
+	return tokens[0]
+}
@@ -3,6 +3,7 @@ package sender
 import (
 	"context"
 	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	"github.com/segmentio/kafka-go"
 	"time"
 )
@@ -19,6 +20,11 @@ type KafkaSender struct {
 }
 
 func (s *KafkaSender) SendMessage(message []byte) error {
+	return s.SendMessages([][]byte{message})
+}
+
+// SendMessages writes messages in one request.
+func (s *KafkaSender) SendMessages(messages [][]byte) error {
 	w := kafka.Writer{
 		Addr:         kafka.TCP(s.Target.Brokers...),
 		Topic:        s.Target.Topic,
@@ -26,11 +32,20 @@ func (s *KafkaSender) SendMessage(message []byte) error {
 		WriteTimeout: s.Target.WriteTimeout,
 	}
 
-	err := w.WriteMessages(context.Background(),
-		kafka.Message{
+	var kafkaMessages []kafka.Message
+	for _, message := range messages {
+		kafkaMessages = append(kafkaMessages, kafka.Message{
 			Value: message,
-		},
-	)
+			Headers: []kafka.Header{
+				{
+					Key:   tracing.TraceparentHeader,
+					Value: []byte(tracing.NewSpanContext().Traceparent()),
+				},
+			},
+		})
+	}
+
+	err := w.WriteMessages(context.Background(), kafkaMessages...)
 
 	if err != nil {
 		return fmt.Errorf("send message failed: %s", err)
@@ -0,0 +1,18 @@
+package sender
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewMessageID generates a random UUID (version 4) as message id.
+func NewMessageID() string {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		panic(fmt.Errorf("generate message id has error: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
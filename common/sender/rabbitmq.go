@@ -2,6 +2,7 @@ package sender
 
 import (
 	"fmt"
+	"github.com/nwpc-oper/nwpc-message-client/common/tracing"
 	"github.com/streadway/amqp"
 	"time"
 )
@@ -14,11 +15,18 @@ type RabbitMQTarget struct {
 }
 
 type RabbitMQSender struct {
-	Target RabbitMQTarget
-	Debug  bool
+	Target    RabbitMQTarget
+	MessageID string
+	Headers   amqp.Table
+	Debug     bool
 }
 
 func (s *RabbitMQSender) SendMessage(message []byte) error {
+	return s.SendMessages([][]byte{message})
+}
+
+// SendMessages publishes messages using one connection. MessageID and Headers are used for all messages.
+func (s *RabbitMQSender) SendMessages(messages [][]byte) error {
 	connection, err := amqp.Dial(s.Target.Server)
 	if err != nil {
 		return fmt.Errorf("dial to rabbitmq has error: %s", err)
@@ -44,19 +52,36 @@ func (s *RabbitMQSender) SendMessage(message []byte) error {
 		return fmt.Errorf("create exchange has error: %s", err)
 	}
 
-	err = channel.Publish(
-		s.Target.Exchange,
-		s.Target.RouteKey,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType:  "text/plain",
-			DeliveryMode: amqp.Persistent,
-			Body:         message,
-		})
-	if err != nil {
-		return fmt.Errorf("publish message has error: %s", err)
+	for index, message := range messages {
+		err = channel.Publish(
+			s.Target.Exchange,
+			s.Target.RouteKey,
+			false,
+			false,
+			amqp.Publishing{
+				ContentType:  "text/plain",
+				DeliveryMode: amqp.Persistent,
+				MessageId:    s.MessageID,
+				Headers:      s.createHeaders(),
+				Body:         message,
+			})
+		if err != nil {
+			return fmt.Errorf("publish message %d of %d has error: %s", index+1, len(messages), err)
+		}
 	}
 
 	return nil
 }
+
+// start a new trace for each message if message is not from broker.
+func (s *RabbitMQSender) createHeaders() amqp.Table {
+	if _, ok := s.Headers[tracing.TraceparentHeader]; ok {
+		return s.Headers
+	}
+	headers := amqp.Table{}
+	for key, value := range s.Headers {
+		headers[key] = value
+	}
+	headers[tracing.TraceparentHeader] = tracing.NewSpanContext().Traceparent()
+	return headers
+}
@@ -3,3 +3,8 @@ package sender
 type Sender interface {
 	SendMessage([]byte) error
 }
+
+// BatchSender sends multiple messages using one connection.
+type BatchSender interface {
+	SendMessages([][]byte) error
+}
@@ -0,0 +1,198 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyslogMessage is a syslog message in RFC 5424 or RFC 3164 format.
+type SyslogMessage struct {
+	Facility       int
+	Severity       int
+	Time           time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string // SD-ID => param name => param value
+	Message        string
+}
+
+var syslogSeverityList = [8]string{
+	"emergency",
+	"alert",
+	"critical",
+	"error",
+	"warning",
+	"notice",
+	"info",
+	"debug",
+}
+
+// SeverityLevel returns log level name of syslog severity, such as error, warning and info.
+func (m *SyslogMessage) SeverityLevel() string {
+	if m.Severity < 0 || m.Severity >= len(syslogSeverityList) {
+		return "unknown"
+	}
+	return syslogSeverityList[m.Severity]
+}
+
+// ParseSyslogMessage parses one syslog line. RFC 5424 is used if version is 1 after PRI,
+// otherwise RFC 3164 is used.
+func ParseSyslogMessage(line string) (*SyslogMessage, error) {
+	line = strings.TrimRight(line, "\r\n\x00")
+	if !strings.HasPrefix(line, "<") {
+		return nil, fmt.Errorf("PRI is not found")
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return nil, fmt.Errorf("PRI is invalid")
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri > 191 {
+		return nil, fmt.Errorf("PRI is invalid: %s", line[1:end])
+	}
+
+	message := &SyslogMessage{
+		Facility:       pri / 8,
+		Severity:       pri % 8,
+		StructuredData: make(map[string]map[string]string),
+	}
+	rest := line[end+1:]
+	if strings.HasPrefix(rest, "1 ") {
+		err = message.parseRFC5424(rest[2:])
+	} else {
+		err = message.parseRFC3164(rest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// parse HEADER SP STRUCTURED-DATA [SP MSG] after VERSION.
+func (m *SyslogMessage) parseRFC5424(content string) error {
+	fields := strings.SplitN(content, " ", 6)
+	if len(fields) < 6 {
+		return fmt.Errorf("RFC 5424 header is incomplete")
+	}
+
+	if fields[0] != "-" {
+		t, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("parse timestamp %s has error: %v", fields[0], err)
+		}
+		m.Time = t
+	}
+	m.Hostname = nilValue(fields[1])
+	m.AppName = nilValue(fields[2])
+	m.ProcID = nilValue(fields[3])
+	m.MsgID = nilValue(fields[4])
+
+	rest, err := m.parseStructuredData(fields[5])
+	if err != nil {
+		return err
+	}
+	m.Message = strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff")
+	return nil
+}
+
+// parse STRUCTURED-DATA and return remain string.
+func (m *SyslogMessage) parseStructuredData(content string) (string, error) {
+	if strings.HasPrefix(content, "-") {
+		return content[1:], nil
+	}
+	for strings.HasPrefix(content, "[") {
+		end := strings.IndexAny(content, " ]")
+		if end == -1 {
+			return "", fmt.Errorf("SD-ELEMENT is not closed")
+		}
+		id := content[1:end]
+		params := make(map[string]string)
+		content = content[end:]
+		for strings.HasPrefix(content, " ") {
+			content = content[1:]
+			equal := strings.Index(content, `="`)
+			if equal == -1 {
+				return "", fmt.Errorf("SD-PARAM is invalid in %s", id)
+			}
+			name := content[:equal]
+			value, remain, err := parseParamValue(content[equal+2:])
+			if err != nil {
+				return "", fmt.Errorf("SD-PARAM %s is invalid in %s: %v", name, id, err)
+			}
+			params[name] = value
+			content = remain
+		}
+		if !strings.HasPrefix(content, "]") {
+			return "", fmt.Errorf("SD-ELEMENT %s is not closed", id)
+		}
+		content = content[1:]
+		m.StructuredData[id] = params
+	}
+	return content, nil
+}
+
+// parse PARAM-VALUE until closing quote. '"', '\' and ']' are escaped with '\'.
+func parseParamValue(content string) (string, string, error) {
+	var value strings.Builder
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content) && strings.IndexByte(`"\]`, content[i+1]) != -1:
+			value.WriteByte(content[i+1])
+			i++
+		case c == '"':
+			return value.String(), content[i+1:], nil
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("PARAM-VALUE is not closed")
+}
+
+// parse TIMESTAMP HOSTNAME TAG: MSG. Year is not included in timestamp, use current year.
+func (m *SyslogMessage) parseRFC3164(content string) error {
+	const timestampLayout = "Jan _2 15:04:05"
+	if len(content) >= len(timestampLayout)+1 {
+		t, err := time.ParseInLocation(timestampLayout, content[:len(timestampLayout)], time.Local)
+		if err == nil {
+			now := time.Now()
+			t = t.AddDate(now.Year(), 0, 0)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			m.Time = t
+			content = strings.TrimPrefix(content[len(timestampLayout):], " ")
+
+			fields := strings.SplitN(content, " ", 2)
+			if len(fields) == 2 && !strings.HasSuffix(fields[0], ":") {
+				m.Hostname = fields[0]
+				content = fields[1]
+			}
+		}
+	}
+
+	colon := strings.Index(content, ": ")
+	if colon == -1 || strings.ContainsAny(content[:colon], " ") {
+		m.Message = content
+		return nil
+	}
+	tag := content[:colon]
+	if start := strings.IndexByte(tag, '['); start != -1 && strings.HasSuffix(tag, "]") {
+		m.ProcID = tag[start+1 : len(tag)-1]
+		tag = tag[:start]
+	}
+	m.AppName = tag
+	m.Message = content[colon+2:]
+	return nil
+}
+
+func nilValue(value string) string {
+	if value == "-" {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+// Package tracing propagates W3C trace context across hops of messages and records spans of hops.
+//
+// It is not an OpenTelemetry SDK. Spans are written by its own exporters in JSON Lines format,
+// and can't be sent to OpenTelemetry collectors. Only the traceparent header is interoperable
+// with other tracing systems.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceparentHeader is the header name of W3C trace context, used in gRPC metadata,
+// HTTP headers, AMQP headers and Kafka headers.
+const TraceparentHeader = "traceparent"
+
+const sampledFlag = 0x01
+
+// SpanContext is the W3C trace context of a span.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// NewSpanContext creates a sampled span context with new trace id, which starts a new trace.
+func NewSpanContext() SpanContext {
+	sc := SpanContext{Flags: sampledFlag}
+	randomBytes(sc.TraceID[:])
+	randomBytes(sc.SpanID[:])
+	return sc
+}
+
+// ParseTraceparent parses traceparent header, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	tokens := strings.Split(strings.TrimSpace(value), "-")
+	if len(tokens) < 4 || len(tokens[0]) != 2 || tokens[0] == "ff" {
+		return sc, fmt.Errorf("traceparent is invalid: %s", value)
+	}
+	if tokens[0] == "00" && len(tokens) != 4 {
+		return sc, fmt.Errorf("traceparent is invalid: %s", value)
+	}
+	if err := decodeHex(sc.TraceID[:], tokens[1]); err != nil {
+		return sc, fmt.Errorf("trace id is invalid: %v", err)
+	}
+	if err := decodeHex(sc.SpanID[:], tokens[2]); err != nil {
+		return sc, fmt.Errorf("span id is invalid: %v", err)
+	}
+	var flags [1]byte
+	if err := decodeHex(flags[:], tokens[3]); err != nil {
+		return sc, fmt.Errorf("trace flags is invalid: %v", err)
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("traceparent is invalid: %s", value)
+	}
+	return sc, nil
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+func (sc SpanContext) SpanIDString() string {
+	return hex.EncodeToString(sc.SpanID[:])
+}
+
+// Traceparent returns traceparent header value with version 00.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceIDString(), sc.SpanIDString(), sc.Flags)
+}
+
+// child returns a span context in the same trace with a new span id.
+// A new trace is started if sc is invalid.
+func (sc SpanContext) child() SpanContext {
+	if !sc.IsValid() {
+		return NewSpanContext()
+	}
+	child := sc
+	randomBytes(child.SpanID[:])
+	return child
+}
+
+// ExtractHeaders gets span context from headers, such as AMQP headers.
+func ExtractHeaders(headers map[string]interface{}) SpanContext {
+	value, ok := headers[TraceparentHeader]
+	if !ok {
+		return SpanContext{}
+	}
+	var traceparent string
+	switch v := value.(type) {
+	case string:
+		traceparent = v
+	case []byte:
+		traceparent = string(v)
+	default:
+		return SpanContext{}
+	}
+	sc, _ := ParseTraceparent(traceparent)
+	return sc
+}
+
+func decodeHex(target []byte, value string) error {
+	if len(value) != hex.EncodedLen(len(target)) || strings.ToLower(value) != value {
+		return fmt.Errorf("length or case is invalid: %s", value)
+	}
+	_, err := hex.Decode(target, []byte(value))
+	return err
+}
+
+func randomBytes(b []byte) {
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(fmt.Errorf("generate random id has error: %v", err))
+	}
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Exporter sends ended spans to a backend.
+type Exporter interface {
+	ExportSpan(span *Span) error
+	Close() error
+}
+
+// CreateExporter creates exporter by name: none, stdout or file.
+// Return nil for none.
+func CreateExporter(name string, filePath string) (Exporter, error) {
+	switch name {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return &WriterExporter{Writer: os.Stdout}, nil
+	case ExporterFile:
+		if len(filePath) == 0 {
+			return nil, fmt.Errorf("file path is required for file exporter")
+		}
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file has error: %v", err)
+		}
+		return &WriterExporter{Writer: file, Closer: file}, nil
+	default:
+		return nil, fmt.Errorf("trace exporter is not supported: %s", name)
+	}
+}
+
+// WriterExporter writes spans to Writer in JSON Lines format.
+type WriterExporter struct {
+	Writer io.Writer
+	Closer io.Closer
+
+	lock sync.Mutex
+}
+
+func (e *WriterExporter) ExportSpan(span *Span) error {
+	span.lock.Lock()
+	content, err := json.Marshal(span)
+	span.lock.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshal span has error: %v", err)
+	}
+	content = append(content, '\n')
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	_, err = e.Writer.Write(content)
+	return err
+}
+
+func (e *WriterExporter) Close() error {
+	if e.Closer == nil {
+		return nil
+	}
+	return e.Closer.Close()
+}
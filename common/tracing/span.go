@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// span kinds, named after OpenTelemetry span kinds.
+const (
+	SpanKindInternal = "internal"
+	SpanKindServer   = "server"
+	SpanKindClient   = "client"
+	SpanKindProducer = "producer"
+	SpanKindConsumer = "consumer"
+)
+
+// span status codes, named after OpenTelemetry status codes.
+const (
+	StatusUnset = "unset"
+	StatusOk    = "ok"
+	StatusError = "error"
+)
+
+// Span is one hop of a message. Fields are similar to OpenTelemetry span, but span is exported in its own JSON format.
+type Span struct {
+	Name          string                 `json:"name"`
+	Kind          string                 `json:"kind"`
+	Service       string                 `json:"service"`
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	StartTime     time.Time              `json:"start_time"`
+	EndTime       time.Time              `json:"end_time"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Status        string                 `json:"status"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+
+	context SpanContext
+	tracer  *Tracer
+	lock    sync.Mutex
+	ended   bool
+}
+
+// Context returns span context to propagate to the next hop.
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+	s.Attributes[key] = value
+}
+
+// SetError sets status to error if err is not nil, otherwise sets status to ok.
+func (s *Span) SetError(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err != nil {
+		s.Status = StatusError
+		s.StatusMessage = err.Error()
+	} else {
+		s.Status = StatusOk
+		s.StatusMessage = ""
+	}
+}
+
+// End sets end time and exports span. Only the first call takes effect.
+func (s *Span) End() {
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.lock.Unlock()
+
+	s.tracer.export(s)
+}
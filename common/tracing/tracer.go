@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// Tracer creates spans for a service and exports ended spans by Exporter.
+//
+// A nil Tracer is valid: spans are still created to propagate trace context, but not exported.
+type Tracer struct {
+	Service  string
+	Exporter Exporter
+}
+
+// StartSpan starts a span as a child of parent. A new trace is started if parent is invalid.
+func (t *Tracer) StartSpan(name string, kind string, parent SpanContext) *Span {
+	sc := parent.child()
+	span := &Span{
+		Name:      name,
+		Kind:      kind,
+		TraceID:   sc.TraceIDString(),
+		SpanID:    sc.SpanIDString(),
+		StartTime: time.Now(),
+		Status:    StatusUnset,
+		context:   sc,
+		tracer:    t,
+	}
+	if parent.IsValid() {
+		span.ParentSpanID = parent.SpanIDString()
+	}
+	if t != nil {
+		span.Service = t.Service
+	}
+	return span
+}
+
+func (t *Tracer) Close() error {
+	if t == nil || t.Exporter == nil {
+		return nil
+	}
+	return t.Exporter.Close()
+}
+
+func (t *Tracer) export(span *Span) {
+	if t == nil || t.Exporter == nil {
+		return
+	}
+	err := t.Exporter.ExportSpan(span)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"component": "tracing",
+			"event":     "export",
+		}).Warnf("export span has error: %v", err)
+	}
+}
+
+// CreateTracer creates a tracer for service with exporter by name, see CreateExporter.
+func CreateTracer(service string, exporterName string, filePath string) (*Tracer, error) {
+	exporter, err := CreateExporter(exporterName, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracer{
+		Service:  service,
+		Exporter: exporter,
+	}, nil
+}